@@ -1,31 +1,78 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"github.com/arjunmoola/go-racer/internal/racer"
 	"log"
 	"os"
 )
 
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: racer [flags]\n")
+	fmt.Fprintf(out, "       racer [flags] add-test [-f file] [-d directory]\n")
+	fmt.Fprintf(out, "       racer [flags] replay <test-id>\n")
+	fmt.Fprintf(out, "       racer [flags] db migrate|status\n")
+	fmt.Fprintf(out, "       racer [flags] export [--format csv|jsonl|parquet] [--since date] --out file\n")
+	fmt.Fprintf(out, "       racer [flags] import monkeytype <file.csv>\n\n")
+	fmt.Fprintf(out, "flags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nRACER_HOME sets the directory that holds the config file and all data.\n")
+}
+
 func main() {
-	args := os.Args
+	var challenge string
+	var paths racer.Paths
+
+	flag.StringVar(&challenge, "challenge", "", "start the test described by a challenge code")
+	flag.StringVar(&paths.ConfigFile, "config", "", "path of the config file")
+	flag.StringVar(&paths.DataDir, "data-dir", "", "directory that holds the quotes, word lists and database")
+	flag.StringVar(&paths.WordsDir, "words-dir", "", "directory that holds the word lists")
+	flag.StringVar(&paths.DbFile, "db", "", "path of the database file")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+
+	if len(args) > 0 {
+		var err error
 
-	if len(args) > 2 {
-		switch args[1] {
+		switch args[0] {
 		case "add-test":
-			if err := racer.RunAddTest(args[2:]); err != nil {
-				log.Fatal(err)
-			}
+			err = racer.RunAddTest(paths, args[1:])
+		case "replay":
+			err = racer.RunReplay(paths, args[1:])
+		case "db":
+			err = racer.RunDb(paths, args[1:])
+		case "export":
+			err = racer.RunExport(paths, args[1:])
+		case "import":
+			err = racer.RunImport(paths, args[1:])
+		default:
+			flag.Usage()
+			os.Exit(2)
+		}
+
+		if err != nil {
+			log.Fatal(err)
 		}
 
 		return
 	}
 
-	racerModel, err := racer.NewRacerModel()
+	racerModel, err := racer.NewRacerModel(paths)
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if challenge != "" {
+		if err := racerModel.SetChallenge(challenge); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if err := racerModel.Run(); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,262 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// codes of the edits a keystroke can make to the input
+const (
+	OpMatch = "m"
+	OpMismatch = "s"
+	OpDelete = "d"
+	// a mistyped key the stop mode refused, it never enters the input
+	OpReject = "r"
+)
+
+type EditOp interface {
+	Rune() rune
+	String() string
+	Code() string
+}
+
+type MatchOp rune
+
+func (op MatchOp) String() string {
+	return fmt.Sprintf("m(%s)", string(op))
+}
+
+func (op MatchOp) Rune() rune {
+	return rune(op)
+}
+
+func (op MatchOp) Code() string {
+	return OpMatch
+}
+
+type MismatchOp rune
+
+func (op MismatchOp) String() string {
+	return fmt.Sprintf("s(%s)", string(op))
+}
+
+func (op MismatchOp) Rune() rune {
+	return rune(op)
+}
+
+func (op MismatchOp) Code() string {
+	return OpMismatch
+}
+
+type DeleteOp rune
+
+func (op DeleteOp) String() string {
+	return fmt.Sprintf("d(%s)", string(op))
+}
+
+func (op DeleteOp) Rune() rune {
+	return rune(op)
+}
+
+func (op DeleteOp) Code() string {
+	return OpDelete
+}
+
+type RejectOp rune
+
+func (op RejectOp) String() string {
+	return fmt.Sprintf("r(%s)", string(op))
+}
+
+func (op RejectOp) Rune() rune {
+	return rune(op)
+}
+
+func (op RejectOp) Code() string {
+	return OpReject
+}
+
+// Alignment is the sequence of edits that produced the input of a test.
+type Alignment []EditOp
+
+func (a Alignment) String() string {
+	builder := &strings.Builder{}
+
+	for _, op := range a {
+		builder.WriteString(op.String())
+	}
+
+	return builder.String()
+}
+
+// Rle run length encodes the codes of the edits, for example "3ms" for
+// three matches followed by a mismatch.
+func (a Alignment) Rle() string {
+	builder := &strings.Builder{}
+
+	if len(a) == 0 {
+		return ""
+	}
+
+	if len(a) == 1 {
+		return a[0].Code()
+	}
+
+	matchLen := 1
+	prevOp := a[0]
+
+	for i := 1; i < len(a); i++ {
+		op := a[i]
+
+		if op.Code() == prevOp.Code() {
+			matchLen++
+		} else {
+			if matchLen == 1 {
+				fmt.Fprintf(builder, "%s", prevOp.Code())
+			} else {
+				fmt.Fprintf(builder, "%d%s", matchLen, prevOp.Code())
+				matchLen = 1
+			}
+		}
+		prevOp = op
+	}
+
+	if matchLen == 1 {
+		fmt.Fprintf(builder, "%s", prevOp.Code())
+	} else {
+		fmt.Fprintf(builder, "%d%s", matchLen, prevOp.Code())
+	}
+
+	return builder.String()
+}
+
+// ParseAlignment rebuilds an alignment from its Rle and RawString, which is
+// how tests are stored.
+func ParseAlignment(rle string, raw string) (Alignment, error) {
+	chars := []rune(raw)
+	a := make(Alignment, 0, len(chars))
+	count := 0
+
+	for _, c := range rle {
+		if c >= '0' && c <= '9' {
+			count = count*10 + int(c - '0')
+			continue
+		}
+
+		count = max(count, 1)
+
+		if len(a) + count > len(chars) {
+			return nil, fmt.Errorf("rle %q is longer than the input %q", rle, raw)
+		}
+
+		for range count {
+			char := chars[len(a)]
+
+			switch string(c) {
+			case OpMatch:
+				a = append(a, MatchOp(char))
+			case OpMismatch:
+				a = append(a, MismatchOp(char))
+			case OpDelete:
+				a = append(a, DeleteOp(char))
+			case OpReject:
+				a = append(a, RejectOp(char))
+			default:
+				return nil, fmt.Errorf("invalid edit %q in rle %q", c, rle)
+			}
+		}
+
+		count = 0
+	}
+
+	if count != 0 || len(a) != len(chars) {
+		return nil, fmt.Errorf("rle %q does not match the input %q", rle, raw)
+	}
+
+	return a, nil
+}
+
+// Final returns every character typed that was not deleted afterwards or
+// rejected.
+func (a Alignment) Final() string {
+	builder := &strings.Builder{}
+
+	for _, op := range a {
+		if op.Code() == OpDelete || op.Code() == OpReject {
+			continue
+		}
+
+		builder.WriteRune(op.Rune())
+	}
+
+	return builder.String()
+}
+
+// Result returns the edits that are left in the input once every deleted
+// character is removed, one for each character of the input.
+func (a Alignment) Result() Alignment {
+	result := make(Alignment, 0, len(a))
+
+	for _, op := range a {
+		switch op.Code() {
+		case OpDelete:
+			result = result[:max(len(result) - 1, 0)]
+			continue
+		case OpReject:
+			continue
+		}
+
+		result = append(result, op)
+	}
+
+	return result
+}
+
+// RawString returns the character of every edit, including the ones that
+// were deleted and the deletions themselves.
+func (a Alignment) RawString() string {
+	builder := &strings.Builder{}
+
+	for _, op := range a {
+		builder.WriteRune(op.Rune())
+	}
+	return builder.String()
+}
+
+// Keystroke is a single key event recorded while a test is running. Offset
+// is measured from the start of the test, Expected is the target character
+// under the cursor and Op is the code of the edit the key produced.
+type Keystroke struct {
+	Offset time.Duration
+	Expected rune
+	Typed rune
+	Op string
+}
+
+// Mistyped reports whether the key was not the expected one, whether it
+// was accepted or rejected.
+func (k Keystroke) Mistyped() bool {
+	return k.Op == OpMismatch || k.Op == OpReject
+}
+
+type KeystrokeLog []Keystroke
+
+func (k KeystrokeLog) Alignment() Alignment {
+	a := make(Alignment, 0, len(k))
+
+	for _, key := range k {
+		switch key.Op {
+		case OpMatch:
+			a = append(a, MatchOp(key.Typed))
+		case OpMismatch:
+			a = append(a, MismatchOp(key.Typed))
+		case OpDelete:
+			a = append(a, DeleteOp(key.Typed))
+		case OpReject:
+			a = append(a, RejectOp(key.Typed))
+		}
+	}
+
+	return a
+}
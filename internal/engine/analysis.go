@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"math"
+	"time"
+)
+
+// RawWpm is the speed of the second the sample was taken in counting every
+// character typed, correct or not.
+func (s Sample) RawWpm() int {
+	return s.Cps*60/5
+}
+
+// Consistency is the coefficient of variation of the characters typed in
+// each second. Zero means every second was typed at the same speed.
+func Consistency(samples []Sample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+
+	var sum float64
+
+	for _, s := range samples {
+		sum += float64(s.Cps)
+	}
+
+	mean := sum/float64(len(samples))
+
+	if mean == 0 {
+		return 0
+	}
+
+	var variance float64
+
+	for _, s := range samples {
+		d := float64(s.Cps) - mean
+		variance += d*d
+	}
+
+	variance /= float64(len(samples))
+
+	return math.Sqrt(variance)/mean
+}
+
+// ErrorsPerSecond counts the mistyped characters in each of the first
+// seconds of the test. Mistakes made after the last second are counted in
+// the last one.
+func (k KeystrokeLog) ErrorsPerSecond(seconds int) []int {
+	if seconds <= 0 {
+		return nil
+	}
+
+	errors := make([]int, seconds)
+
+	for _, key := range k {
+		if !key.Mistyped() {
+			continue
+		}
+
+		errors[min(int(key.Offset/time.Second), seconds-1)]++
+	}
+
+	return errors
+}
+
+// FirstError returns when the first character was mistyped.
+func (k KeystrokeLog) FirstError() (time.Duration, bool) {
+	for _, key := range k {
+		if key.Mistyped() {
+			return key.Offset, true
+		}
+	}
+
+	return 0, false
+}
+
+// KeyStat counts how often a character or bigram was typed and mistyped
+// and the time it took to type. Latencies is the number of keystrokes the
+// latency is known for.
+type KeyStat struct {
+	Attempts int
+	Errors int
+	Latency time.Duration
+	Latencies int
+}
+
+// ErrorRate is the fraction of the attempts that were mistyped.
+func (s *KeyStat) ErrorRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+
+	return float64(s.Errors)/float64(s.Attempts)
+}
+
+// AvgLatency is the mean time between the previous keystroke and typing the
+// character.
+func (s *KeyStat) AvgLatency() time.Duration {
+	if s.Latencies == 0 {
+		return 0
+	}
+
+	return s.Latency/time.Duration(s.Latencies)
+}
+
+func (s *KeyStat) add(mistyped bool, latency time.Duration, known bool) {
+	s.Attempts++
+
+	if mistyped {
+		s.Errors++
+	}
+
+	if known {
+		s.Latency += latency
+		s.Latencies++
+	}
+}
+
+// KeyStats aggregates keystrokes by the character that was expected and by
+// the bigram of the previous and the expected character.
+type KeyStats struct {
+	Chars map[rune]*KeyStat
+	Bigrams map[string]*KeyStat
+}
+
+func NewKeyStats() *KeyStats {
+	return &KeyStats{
+		Chars: make(map[rune]*KeyStat),
+		Bigrams: make(map[string]*KeyStat),
+	}
+}
+
+func (s *KeyStats) char(c rune) *KeyStat {
+	stat, ok := s.Chars[c]
+
+	if !ok {
+		stat = &KeyStat{}
+		s.Chars[c] = stat
+	}
+
+	return stat
+}
+
+func (s *KeyStats) bigram(b string) *KeyStat {
+	stat, ok := s.Bigrams[b]
+
+	if !ok {
+		stat = &KeyStat{}
+		s.Bigrams[b] = stat
+	}
+
+	return stat
+}
+
+// Add counts the keystrokes of a test. Deletions are not counted and the
+// keystroke after a deletion or a rejected key has neither a latency nor a
+// bigram since the key before it was not the character before it in the
+// target.
+func (s *KeyStats) Add(log KeystrokeLog) {
+	for i, key := range log {
+		if key.Op == OpDelete {
+			continue
+		}
+
+		mistyped := key.Mistyped()
+		follows := i > 0 && log[i-1].Op != OpDelete && log[i-1].Op != OpReject
+		var latency time.Duration
+
+		if follows {
+			latency = key.Offset - log[i-1].Offset
+		}
+
+		s.char(key.Expected).add(mistyped, latency, follows)
+
+		if follows {
+			s.bigram(string([]rune{ log[i-1].Expected, key.Expected })).add(mistyped, latency, true)
+		}
+	}
+}
+
+// WordResult is how a word of the target was typed. Mistakes counts the
+// mistyped keystrokes in the word including the corrected ones and Correct
+// reports whether the word was left typed correctly.
+type WordResult struct {
+	Word string
+	Mistakes int
+	Correct bool
+}
+
+// WordResults returns the result of every word of target that was typed to
+// its end, in the order of the target.
+func WordResults(target []rune, log KeystrokeLog) []WordResult {
+	mistakes := make([]int, len(target))
+	var input []bool
+
+	for _, key := range log {
+		switch key.Op {
+		case OpDelete:
+			input = input[:max(len(input) - 1, 0)]
+		default:
+			if len(input) >= len(target) {
+				continue
+			}
+
+			if key.Mistyped() {
+				mistakes[len(input)]++
+			}
+
+			if key.Op != OpReject {
+				input = append(input, key.Op == OpMatch)
+			}
+		}
+	}
+
+	var results []WordResult
+
+	for start := 0; start < len(target); {
+		if target[start] == ' ' {
+			start++
+			continue
+		}
+
+		end := start
+
+		for end < len(target) && target[end] != ' ' {
+			end++
+		}
+
+		if end > len(input) {
+			break
+		}
+
+		result := WordResult{ Word: string(target[start:end]), Correct: true }
+
+		for i := start; i < end; i++ {
+			result.Mistakes += mistakes[i]
+			result.Correct = result.Correct && input[i]
+		}
+
+		results = append(results, result)
+		start = end
+	}
+
+	return results
+}
@@ -0,0 +1,435 @@
+// Package engine scores typing tests. A Session is fed the target text and
+// timestamped key events and keeps track of everything needed to report on
+// the test. It has no knowledge of the terminal or of the wall clock, so the
+// same events always produce the same results.
+package engine
+
+import (
+	"math"
+	"slices"
+	"time"
+	"golang.org/x/text/unicode/norm"
+)
+
+// StopMode is what a session does when a character is mistyped.
+type StopMode int
+
+const (
+	// StopNever accepts the mistyped character and moves on.
+	StopNever StopMode = iota
+	// StopOnLetter rejects mistyped characters, the cursor only moves once
+	// the right key is typed.
+	StopOnLetter
+	// StopOnWord rejects the space after a word with a mistake in it, and
+	// the last character of the target, until the word is corrected.
+	StopOnWord
+)
+
+// Options control how a Session treats the key events it is fed.
+type Options struct {
+	// Duration is the length of a timed test. A session without a duration
+	// only ends once the whole target has been typed.
+	Duration time.Duration
+	// AllowBackspace enables deleting what was typed. In StopOnWord mode the
+	// current word can always be corrected.
+	AllowBackspace bool
+	Stop StopMode
+}
+
+// Sample is a snapshot of the session taken at the end of every second.
+type Sample struct {
+	Accuracy float64
+	Cps int
+	Wpm int
+}
+
+type Session struct {
+	opts Options
+	target []rune
+	input []rune
+	keystrokes KeystrokeLog
+	now time.Duration
+	finished bool
+
+	numMatches int
+	numMisses int
+	numRejected int
+	accuracy float64
+	numCharsPerSec int
+	samples []Sample
+
+	wordCount int
+	lastWordIdx int
+}
+
+// NewSession creates a session for typing target. The target is normalized
+// to NFC so that precomposed and decomposed accents compare equal to what
+// the keyboard produces.
+func NewSession(target string, opts Options) *Session {
+	return &Session{
+		opts: opts,
+		target: []rune(norm.NFC.String(target)),
+	}
+}
+
+// Reset discards all the progress made so the target can be typed again.
+func (s *Session) Reset() {
+	s.input = nil
+	s.keystrokes = nil
+	s.now = 0
+	s.finished = false
+	s.numMatches = 0
+	s.numMisses = 0
+	s.numRejected = 0
+	s.accuracy = 0
+	s.numCharsPerSec = 0
+	s.samples = nil
+	s.wordCount = 0
+	s.lastWordIdx = 0
+}
+
+func (s *Session) Target() []rune {
+	return s.target
+}
+
+func (s *Session) Input() []rune {
+	return s.input
+}
+
+// Pos is the index of the next character of the target to be typed.
+func (s *Session) Pos() int {
+	return len(s.input)
+}
+
+func (s *Session) Options() Options {
+	return s.opts
+}
+
+func (s *Session) Timed() bool {
+	return s.opts.Duration > 0
+}
+
+func (s *Session) Finished() bool {
+	return s.finished
+}
+
+// Elapsed is the time of the latest event the session has seen.
+func (s *Session) Elapsed() time.Duration {
+	return s.now
+}
+
+// Advance moves the session clock forward to at, taking a sample for every
+// second that has passed. A timed session finishes once its duration is
+// reached. Times earlier than the latest event are ignored.
+func (s *Session) Advance(at time.Duration) {
+	if s.finished || at < s.now {
+		return
+	}
+
+	if s.Timed() && at >= s.opts.Duration {
+		at = s.opts.Duration
+		s.finished = true
+	}
+
+	for len(s.samples) < int(at/time.Second) {
+		s.sample(time.Duration(len(s.samples)+1)*time.Second)
+	}
+
+	s.now = at
+}
+
+func (s *Session) sample(at time.Duration) {
+	s.samples = append(s.samples, Sample{
+		Accuracy: s.accuracy,
+		Cps: s.numCharsPerSec,
+		Wpm: ComputeWpm(s.CorrectChars(), at),
+	})
+	s.numCharsPerSec = 0
+}
+
+// Type records char being typed at the given time. It reports whether the
+// character was accepted, which it is not once the session has finished or
+// when the stop mode rejects it.
+func (s *Session) Type(char rune, at time.Duration) bool {
+	s.Advance(at)
+
+	if s.finished || len(s.input) >= len(s.target) {
+		return false
+	}
+
+	if s.rejects(char) {
+		s.reject(char)
+		return false
+	}
+
+	expected := s.target[len(s.input)]
+	key := Keystroke{
+		Offset: s.now,
+		Expected: expected,
+		Typed: char,
+	}
+
+	if expected == char {
+		key.Op = OpMatch
+		s.numMatches++
+	} else {
+		key.Op = OpMismatch
+		s.numMisses++
+	}
+
+	s.input = append(s.input, char)
+	s.keystrokes = append(s.keystrokes, key)
+	s.numCharsPerSec++
+	s.updateAccuracy()
+
+	wordIdx := lastIndexRune(s.target[:len(s.input)], ' ')
+
+	if wordIdx > 0 && wordIdx != s.lastWordIdx {
+		s.wordCount++
+		s.lastWordIdx = wordIdx
+	}
+
+	if len(s.input) == len(s.target) {
+		s.finished = true
+	}
+
+	return true
+}
+
+// Reject records char being typed at the given time without moving the
+// cursor, the way the stop modes refuse a mistyped character. It reports
+// whether the keystroke was recorded.
+func (s *Session) Reject(char rune, at time.Duration) bool {
+	s.Advance(at)
+
+	if s.finished || len(s.input) >= len(s.target) {
+		return false
+	}
+
+	s.reject(char)
+
+	return true
+}
+
+func (s *Session) reject(char rune) {
+	s.keystrokes = append(s.keystrokes, Keystroke{
+		Offset: s.now,
+		Expected: s.target[len(s.input)],
+		Typed: char,
+		Op: OpReject,
+	})
+	s.numMisses++
+	s.numRejected++
+	s.numCharsPerSec++
+	s.updateAccuracy()
+}
+
+// rejects reports whether the stop mode refuses char at the cursor.
+func (s *Session) rejects(char rune) bool {
+	pos := len(s.input)
+	expected := s.target[pos]
+
+	switch s.opts.Stop {
+	case StopOnLetter:
+		return char != expected
+	case StopOnWord:
+		if expected == ' ' {
+			return char != ' ' || s.wordMistyped(pos)
+		}
+
+		if pos == len(s.target) - 1 {
+			return char != expected || s.wordMistyped(pos)
+		}
+	}
+
+	return false
+}
+
+// wordMistyped reports whether the word the input ends in up to end has a
+// mistake in it.
+func (s *Session) wordMistyped(end int) bool {
+	for i := end - 1; i >= 0 && s.target[i] != ' '; i-- {
+		if s.input[i] != s.target[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateAccuracy counts the rejected keystrokes as mistakes, they never
+// make it into the input.
+func (s *Session) updateAccuracy() {
+	s.accuracy = float64(s.numMatches)/float64(len(s.input) + s.numRejected)
+}
+
+// canDelete reports whether the last character typed can be deleted. Stop
+// on word lets the current word be corrected even without backspace, the
+// test could not go on otherwise.
+func (s *Session) canDelete() bool {
+	if len(s.input) == 0 {
+		return false
+	}
+
+	if s.opts.AllowBackspace {
+		return true
+	}
+
+	return s.opts.Stop == StopOnWord && s.target[len(s.input)-1] != ' '
+}
+
+// Backspace deletes the last character typed. It reports whether anything
+// was deleted, which it is not when backspace is disabled, there is nothing
+// to delete or the session has finished.
+func (s *Session) Backspace(at time.Duration) bool {
+	s.Advance(at)
+
+	if s.finished || !s.canDelete() {
+		return false
+	}
+
+	char := s.input[len(s.input)-1]
+	s.input = s.input[:len(s.input)-1]
+	s.keystrokes = append(s.keystrokes, Keystroke{
+		Offset: s.now,
+		Expected: s.target[len(s.input)],
+		Typed: char,
+		Op: OpDelete,
+	})
+
+	return true
+}
+
+// Finish ends the session at the given time, or at its duration if that
+// comes first.
+func (s *Session) Finish(at time.Duration) {
+	s.Advance(at)
+	s.finished = true
+}
+
+func (s *Session) CorrectChars() int {
+	count := 0
+
+	for i, char := range s.input {
+		if s.target[i] == char {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (s *Session) Accuracy() float64 {
+	return s.accuracy
+}
+
+func (s *Session) Matches() int {
+	return s.numMatches
+}
+
+// Misses counts the mistyped keystrokes, including the rejected ones.
+func (s *Session) Misses() int {
+	return s.numMisses
+}
+
+// Rejected counts the keystrokes the stop mode refused.
+func (s *Session) Rejected() int {
+	return s.numRejected
+}
+
+// Wpm is the net words per minute at the time of the latest event.
+func (s *Session) Wpm() int {
+	return ComputeWpm(s.CorrectChars(), s.now)
+}
+
+// RawWpm counts every character typed, correct or not.
+func (s *Session) RawWpm() int {
+	return ComputeWpm(len(s.input), s.now)
+}
+
+// Cps is the average number of characters typed per second.
+func (s *Session) Cps() int {
+	if len(s.samples) == 0 {
+		return 0
+	}
+
+	total := 0
+
+	for _, sample := range s.samples {
+		total += sample.Cps
+	}
+
+	return total/len(s.samples)
+}
+
+func (s *Session) Samples() []Sample {
+	return s.samples
+}
+
+// WordCount is the number of words the cursor has moved past.
+func (s *Session) WordCount() int {
+	return s.wordCount
+}
+
+func (s *Session) Keystrokes() KeystrokeLog {
+	return s.keystrokes
+}
+
+func (s *Session) Alignment() Alignment {
+	return s.keystrokes.Alignment()
+}
+
+func (s *Session) MismatchedWords() []string {
+	return MismatchedWords(s.target, s.input)
+}
+
+// ComputeWpm converts a number of characters typed over the elapsed
+// duration into words per minute using the standard five characters
+// per word.
+func ComputeWpm(chars int, elapsed time.Duration) int {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	words := float64(chars)/5
+	return int(math.Round(words/elapsed.Minutes()))
+}
+
+// MismatchedWords returns the words of target that were not typed exactly
+// as they appear. A word that was only partially typed when the test ended
+// is counted if the part that was typed contains a mistake.
+func MismatchedWords(target []rune, input []rune) []string {
+	n := min(len(input), len(target))
+	var words []string
+	leftIdx := 0
+
+	for i := 0; i <= n; i++ {
+		if i < n && target[i] != ' ' {
+			continue
+		}
+
+		if !slices.Equal(target[leftIdx:i], input[leftIdx:i]) {
+			end := i
+
+			for end < len(target) && target[end] != ' ' {
+				end++
+			}
+
+			words = append(words, string(target[leftIdx:end]))
+		}
+
+		leftIdx = i + 1
+	}
+
+	return words
+}
+
+func lastIndexRune(s []rune, r rune) int {
+	for i := len(s)-1; i >= 0; i-- {
+		if s[i] == r {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,495 @@
+package engine
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func typeString(s *Session, input string, start time.Duration, step time.Duration) time.Duration {
+	at := start
+
+	for _, char := range input {
+		s.Type(char, at)
+		at += step
+	}
+
+	return at
+}
+
+func TestComputeWpm(t *testing.T) {
+	tests := []struct{
+		chars int
+		elapsed time.Duration
+		want int
+	}{
+		{ 0, time.Minute, 0 },
+		{ 250, time.Minute, 50 },
+		{ 125, 30*time.Second, 50 },
+		{ 50, 0, 0 },
+	}
+
+	for _, test := range tests {
+		if got := ComputeWpm(test.chars, test.elapsed); got != test.want {
+			t.Errorf("ComputeWpm(%d, %v) got %d wanted %d", test.chars, test.elapsed, got, test.want)
+		}
+	}
+}
+
+func TestSessionWpm(t *testing.T) {
+	s := NewSession("hello world", Options{})
+
+	typeString(s, "hellp", 0, 0)
+	s.Advance(6*time.Second)
+
+	if got := s.Wpm(); got != 8 {
+		t.Errorf("incorrect net wpm got %d wanted %d", got, 8)
+	}
+
+	if got := s.RawWpm(); got != 10 {
+		t.Errorf("incorrect raw wpm got %d wanted %d", got, 10)
+	}
+
+	if got := s.Accuracy(); got != 0.8 {
+		t.Errorf("incorrect accuracy got %v wanted %v", got, 0.8)
+	}
+}
+
+func TestSessionUnicodeTargets(t *testing.T) {
+	tests := []struct{
+		target string
+		input string
+		wpm int
+		missed []string
+	}{
+		{ "café naïve", "café naive", 9, []string{ "naïve" } },
+		{ "привет мир", "привет мир", 10, nil },
+		{ "γειά σου", "γειά σοθ", 7, []string{ "σου" } },
+		{ "日本語 中文", "日本語 中文", 6, nil },
+	}
+
+	for _, test := range tests {
+		s := NewSession(test.target, Options{})
+		n := len([]rune(test.input))
+
+		// the last character lands exactly on the 12 second mark
+		typeString(s, test.input, 0, 12*time.Second/time.Duration(n-1))
+
+		if !s.Finished() {
+			t.Errorf("%q not finished after typing %d runes of %d", test.target, s.Pos(), len(s.Target()))
+		}
+
+		if got := s.Wpm(); got != test.wpm {
+			t.Errorf("%q incorrect wpm got %d wanted %d", test.target, got, test.wpm)
+		}
+
+		if got := s.MismatchedWords(); !slices.Equal(got, test.missed) {
+			t.Errorf("%q incorrect missed words got %v wanted %v", test.target, got, test.missed)
+		}
+	}
+}
+
+func TestSessionNormalizesTarget(t *testing.T) {
+	// e followed by a combining acute accent
+	s := NewSession("cafe\u0301", Options{})
+
+	if !slices.Equal(s.Target(), []rune("café")) {
+		t.Errorf("target was not normalized got %q", string(s.Target()))
+	}
+}
+
+func TestSessionKeystrokes(t *testing.T) {
+	s := NewSession("ñéo", Options{ AllowBackspace: true })
+
+	s.Type('ñ', 100*time.Millisecond)
+	s.Type('e', 200*time.Millisecond)
+	s.Backspace(300*time.Millisecond)
+	s.Type('é', 400*time.Millisecond)
+
+	want := KeystrokeLog{
+		{ 100*time.Millisecond, 'ñ', 'ñ', OpMatch },
+		{ 200*time.Millisecond, 'é', 'e', OpMismatch },
+		{ 300*time.Millisecond, 'é', 'e', OpDelete },
+		{ 400*time.Millisecond, 'é', 'é', OpMatch },
+	}
+
+	if !slices.Equal(s.Keystrokes(), want) {
+		t.Errorf("incorrect keystrokes got %+v wanted %+v", s.Keystrokes(), want)
+	}
+
+	a := s.Alignment()
+
+	if rle := a.Rle(); rle != "msdm" {
+		t.Errorf("incorrect rle got %s wanted %s", rle, "msdm")
+	}
+
+	if raw := a.RawString(); raw != "ñeeé" {
+		t.Errorf("incorrect raw string got %s wanted %s", raw, "ñeeé")
+	}
+
+	if final := a.Final(); final != "ñeé" {
+		t.Errorf("incorrect final string got %s wanted %s", final, "ñeé")
+	}
+
+	if s.Finished() {
+		t.Errorf("session finished before the target was typed")
+	}
+
+	parsed, err := ParseAlignment(a.Rle(), a.RawString())
+
+	if err != nil || !slices.Equal(parsed, a) {
+		t.Errorf("parsed alignment got %v %v wanted %v", parsed, err, a)
+	}
+
+	if got, want := a.Result(), (Alignment{ MatchOp('ñ'), MatchOp('é') }); !slices.Equal(got, want) {
+		t.Errorf("alignment result got %v wanted %v", got, want)
+	}
+}
+
+func TestSessionStopOnLetter(t *testing.T) {
+	s := NewSession("ab", Options{ Stop: StopOnLetter })
+
+	for i, char := range "axb" {
+		s.Type(char, time.Duration(i + 1)*100*time.Millisecond)
+	}
+
+	if got := string(s.Input()); got != "ab" || !s.Finished() {
+		t.Errorf("stop on letter input got %q finished %v wanted ab", got, s.Finished())
+	}
+
+	if s.Rejected() != 1 || s.Misses() != 1 || s.Accuracy() != 2.0/3 {
+		t.Errorf("stop on letter got %d rejected %d misses and accuracy %v", s.Rejected(), s.Misses(), s.Accuracy())
+	}
+
+	a := s.Alignment()
+
+	if rle, raw := a.Rle(), a.RawString(); rle != "mrm" || raw != "axb" {
+		t.Errorf("stop on letter alignment got %s %s wanted mrm axb", rle, raw)
+	}
+
+	if parsed, err := ParseAlignment(a.Rle(), a.RawString()); err != nil || !slices.Equal(parsed.Result(), Alignment{ MatchOp('a'), MatchOp('b') }) {
+		t.Errorf("parsed alignment result got %v %v", parsed, err)
+	}
+
+	if at, ok := s.Keystrokes().FirstError(); !ok || at != 200*time.Millisecond {
+		t.Errorf("first error got %v %v wanted the rejected key", at, ok)
+	}
+}
+
+func TestSessionStopOnWord(t *testing.T) {
+	s := NewSession("ab cd", Options{ Stop: StopOnWord })
+	at := time.Duration(0)
+
+	typeAll := func(typed string) {
+		for _, char := range typed {
+			at += 100*time.Millisecond
+
+			if char == '<' {
+				s.Backspace(at)
+			} else {
+				s.Type(char, at)
+			}
+		}
+	}
+
+	// the mistake in the first word keeps the space from being typed and
+	// can be corrected even though backspace is disabled
+	typeAll("ax x")
+
+	if got := string(s.Input()); got != "ax" || s.Rejected() != 2 {
+		t.Errorf("stop on word input got %q with %d rejected wanted ax with 2", got, s.Rejected())
+	}
+
+	typeAll("<b <")
+
+	if got := string(s.Input()); got != "ab " {
+		t.Errorf("stop on word deleted past the word got %q", got)
+	}
+
+	// the last character finishes the test only once the word is correct
+	typeAll("cxd")
+
+	if got := string(s.Input()); got != "ab cd" || !s.Finished() {
+		t.Errorf("stop on word input got %q finished %v wanted ab cd", got, s.Finished())
+	}
+
+	if rle := s.Alignment().Rle(); rle != "ms2rd3mrm" {
+		t.Errorf("stop on word rle got %s", rle)
+	}
+}
+
+func TestParseAlignment(t *testing.T) {
+	a, err := ParseAlignment("3m2sm", "abcxyf")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := a.String(); got != "m(a)m(b)m(c)s(x)s(y)m(f)" {
+		t.Errorf("parsed alignment got %s", got)
+	}
+
+	for _, test := range [][2]string{ { "3m", "ab" }, { "2m", "abc" }, { "mx", "ab" }, { "m2", "ab" } } {
+		if _, err := ParseAlignment(test[0], test[1]); err == nil {
+			t.Errorf("ParseAlignment(%q, %q) accepted", test[0], test[1])
+		}
+	}
+}
+
+func TestSessionBackspace(t *testing.T) {
+	s := NewSession("abc", Options{})
+
+	s.Type('x', 0)
+
+	if s.Backspace(0) {
+		t.Errorf("backspace accepted when it is disabled")
+	}
+
+	s = NewSession("abc", Options{ AllowBackspace: true })
+
+	if s.Backspace(0) {
+		t.Errorf("backspace accepted with nothing typed")
+	}
+
+	s.Type('x', 0)
+
+	if !s.Backspace(0) || s.Pos() != 0 {
+		t.Errorf("backspace not applied got position %d", s.Pos())
+	}
+
+	if s.Misses() != 1 {
+		t.Errorf("deleted mistake not counted got %d misses", s.Misses())
+	}
+}
+
+func TestSessionSamples(t *testing.T) {
+	s := NewSession("aaaa aaaa aaaa", Options{})
+
+	s.Type('a', 500*time.Millisecond)
+	s.Type('a', 900*time.Millisecond)
+	s.Type('b', 1500*time.Millisecond)
+	s.Advance(3200*time.Millisecond)
+
+	want := []Sample{
+		{ Accuracy: 1, Cps: 2, Wpm: 24 },
+		{ Accuracy: 2.0/3, Cps: 1, Wpm: 12 },
+		{ Accuracy: 2.0/3, Cps: 0, Wpm: 8 },
+	}
+
+	if !slices.Equal(s.Samples(), want) {
+		t.Errorf("incorrect samples got %+v wanted %+v", s.Samples(), want)
+	}
+
+	if got := s.Cps(); got != 1 {
+		t.Errorf("incorrect cps got %d wanted %d", got, 1)
+	}
+
+	// time never runs backwards
+	s.Advance(time.Second)
+
+	if s.Elapsed() != 3200*time.Millisecond {
+		t.Errorf("clock moved backwards to %v", s.Elapsed())
+	}
+}
+
+func TestSessionTimed(t *testing.T) {
+	s := NewSession("a a a a a a a a", Options{ Duration: 2*time.Second })
+
+	typeString(s, "a a ", 0, 300*time.Millisecond)
+
+	if s.Finished() {
+		t.Fatalf("timed session finished early")
+	}
+
+	if s.Type('a', 2500*time.Millisecond) {
+		t.Errorf("key accepted after the session ran out of time")
+	}
+
+	if !s.Finished() || s.Elapsed() != 2*time.Second {
+		t.Errorf("session did not finish at its duration got %v", s.Elapsed())
+	}
+
+	if n := len(s.Samples()); n != 2 {
+		t.Errorf("incorrect number of samples got %d wanted %d", n, 2)
+	}
+
+	if got := s.WordCount(); got != 2 {
+		t.Errorf("incorrect word count got %d wanted %d", got, 2)
+	}
+}
+
+func TestSessionFinishedIgnoresInput(t *testing.T) {
+	s := NewSession("ab", Options{ AllowBackspace: true })
+
+	typeString(s, "ab", 0, time.Second)
+
+	if s.Type('c', 5*time.Second) || s.Backspace(5*time.Second) {
+		t.Errorf("input accepted after the session finished")
+	}
+
+	if s.Elapsed() != time.Second {
+		t.Errorf("finished session clock moved got %v", s.Elapsed())
+	}
+}
+
+func TestSessionReset(t *testing.T) {
+	s := NewSession("ab", Options{})
+
+	typeString(s, "ab", 0, time.Second)
+	s.Reset()
+
+	if s.Finished() || s.Pos() != 0 || len(s.Keystrokes()) != 0 || len(s.Samples()) != 0 || s.Elapsed() != 0 {
+		t.Errorf("session not reset")
+	}
+
+	if string(s.Target()) != "ab" {
+		t.Errorf("target changed on reset got %q", string(s.Target()))
+	}
+}
+
+func TestSessionDeterministic(t *testing.T) {
+	run := func() *Session {
+		s := NewSession("the quick brown fox", Options{ AllowBackspace: true })
+		typeString(s, "the quikc", 0, 170*time.Millisecond)
+		s.Backspace(1700*time.Millisecond)
+		s.Backspace(1800*time.Millisecond)
+		typeString(s, "ck brown fox", 1900*time.Millisecond, 150*time.Millisecond)
+		return s
+	}
+
+	a, b := run(), run()
+
+	if !slices.Equal(a.Keystrokes(), b.Keystrokes()) || !slices.Equal(a.Samples(), b.Samples()) || a.Wpm() != b.Wpm() {
+		t.Errorf("the same events produced different results")
+	}
+
+	if !a.Finished() || a.MismatchedWords() != nil {
+		t.Errorf("corrected test not finished cleanly got missed words %v", a.MismatchedWords())
+	}
+}
+
+func TestMismatchedWords(t *testing.T) {
+	target := []rune("one two three")
+
+	tests := []struct{
+		input string
+		want []string
+	}{
+		{ "one two three", nil },
+		{ "one tw", nil },
+		{ "one tx", []string{ "two" } },
+		{ "onx two thref", []string{ "one", "three" } },
+		{ "one  wo", []string{ "two" } },
+		{ "", nil },
+	}
+
+	for _, test := range tests {
+		if got := MismatchedWords(target, []rune(test.input)); !slices.Equal(got, test.want) {
+			t.Errorf("MismatchedWords %q got %v wanted %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestConsistency(t *testing.T) {
+	tests := []struct {
+		cps []int
+		want float64
+	}{
+		{ []int{ 5, 5, 5 }, 0 },
+		{ []int{ 4, 6 }, 0.2 },
+		{ []int{ 0, 0 }, 0 },
+		{ []int{ 7 }, 0 },
+	}
+
+	for _, test := range tests {
+		samples := make([]Sample, 0, len(test.cps))
+
+		for _, cps := range test.cps {
+			samples = append(samples, Sample{ Cps: cps })
+		}
+
+		if got := Consistency(samples); math.Abs(got - test.want) > 1e-9 {
+			t.Errorf("Consistency(%v) got %v wanted %v", test.cps, got, test.want)
+		}
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	s := NewSession("abcd", Options{})
+
+	s.Type('a', 500*time.Millisecond)
+	s.Type('x', 1200*time.Millisecond)
+	s.Type('c', 1500*time.Millisecond)
+	s.Type('y', 2500*time.Millisecond)
+
+	if got, ok := s.Keystrokes().FirstError(); !ok || got != 1200*time.Millisecond {
+		t.Errorf("first error got %v %v wanted %v", got, ok, 1200*time.Millisecond)
+	}
+
+	if got, want := s.Keystrokes().ErrorsPerSecond(2), []int{ 0, 2 }; !slices.Equal(got, want) {
+		t.Errorf("errors per second got %v wanted %v", got, want)
+	}
+
+	if _, ok := NewSession("a", Options{}).Keystrokes().FirstError(); ok {
+		t.Errorf("first error found without keystrokes")
+	}
+
+	if got := (Sample{ Cps: 6 }).RawWpm(); got != 72 {
+		t.Errorf("raw wpm got %d wanted 72", got)
+	}
+}
+
+func TestKeyStats(t *testing.T) {
+	s := NewSession("abab", Options{ AllowBackspace: true })
+
+	s.Type('a', 100*time.Millisecond)
+	s.Type('x', 300*time.Millisecond)
+	s.Backspace(400*time.Millisecond)
+	s.Type('b', 600*time.Millisecond)
+	s.Type('a', 700*time.Millisecond)
+	s.Type('b', 800*time.Millisecond)
+
+	stats := NewKeyStats()
+	stats.Add(s.Keystrokes())
+
+	b := stats.Chars['b']
+
+	if b.Attempts != 3 || b.Errors != 1 || b.AvgLatency() != 150*time.Millisecond {
+		t.Errorf("stats of b got %+v wanted 3 attempts, 1 error and 150ms", b)
+	}
+
+	if got := b.ErrorRate(); math.Abs(got - 1.0/3) > 1e-9 {
+		t.Errorf("error rate of b got %v wanted %v", got, 1.0/3)
+	}
+
+	ab := stats.Bigrams["ab"]
+
+	if ab.Attempts != 2 || ab.Errors != 1 || ab.AvgLatency() != 150*time.Millisecond {
+		t.Errorf("stats of ab got %+v wanted 2 attempts, 1 error and 150ms", ab)
+	}
+
+	if ba := stats.Bigrams["ba"]; ba == nil || ba.Attempts != 1 {
+		t.Errorf("stats of ba got %+v wanted 1 attempt", ba)
+	}
+}
+
+func TestWordResults(t *testing.T) {
+	s := NewSession("the cat sat on", Options{ AllowBackspace: true })
+
+	at := typeString(s, "the cxt ", 0, 100*time.Millisecond)
+	at = typeString(s, "sa", at, 100*time.Millisecond)
+	at = typeString(s, "x", at, 100*time.Millisecond)
+	s.Backspace(at)
+	typeString(s, "t o", at + 100*time.Millisecond, 100*time.Millisecond)
+
+	want := []WordResult{
+		{ "the", 0, true },
+		{ "cat", 1, false },
+		{ "sat", 1, true },
+	}
+
+	if got := WordResults(s.Target(), s.Keystrokes()); !slices.Equal(got, want) {
+		t.Errorf("word results got %+v wanted %+v", got, want)
+	}
+}
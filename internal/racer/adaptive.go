@@ -0,0 +1,82 @@
+package racer
+
+import (
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	defaultAdaptive = "off"
+	// only the keystrokes of the recent tests are used, so keys drop out of
+	// adaptive tests once they are typed well again.
+	adaptiveDays = 30
+	// keys count as typed this many more times without a mistake, so a key
+	// mistyped once in a few attempts is not drilled over and over.
+	adaptivePrior = 10
+)
+
+var adaptiveOptions = []string{ "off", "low", "medium", "high" }
+
+// adaptiveIntensities scale how much more often words with weak keys are
+// picked.
+var adaptiveIntensities = map[string]float64{
+	"off": 0,
+	"low": 2,
+	"medium": 5,
+	"high": 10,
+}
+
+// weakness is the smoothed error rate of a key or bigram.
+func weakness(stat *engine.KeyStat) float64 {
+	if stat == nil {
+		return 0
+	}
+
+	return float64(stat.Errors)/float64(stat.Attempts + adaptivePrior)
+}
+
+// wordWeights weighs every word by the weakness of its characters and
+// bigrams. A word without weak keys has a weight of one, so the weights
+// fall back to a uniform choice as the accuracy of the keys improves.
+func wordWeights(words []string, stats *engine.KeyStats, intensity float64) []float64 {
+	weights := make([]float64, len(words))
+
+	for i, word := range words {
+		chars := []rune(word)
+		score := 0.0
+
+		for j, char := range chars {
+			score += weakness(stats.Chars[char])
+
+			if j > 0 {
+				score += weakness(stats.Bigrams[string(chars[j-1:j+1])])
+			}
+		}
+
+		weights[i] = 1 + intensity*score
+	}
+
+	return weights
+}
+
+type adaptiveStatsErr error
+type adaptiveStatsMsg struct {
+	stats *engine.KeyStats
+}
+
+// getAdaptiveStatsCmd loads the key stats adaptive tests are weighted by.
+func (r *RacerModel) getAdaptiveStatsCmd() tea.Cmd {
+	return func() tea.Msg {
+		filter := &StatsFilter{ Since: time.Now().AddDate(0, 0, -adaptiveDays) }
+		stats, err := r.store.GetKeyStats(filter)
+
+		if err != nil {
+			return adaptiveStatsErr(err)
+		}
+
+		return adaptiveStatsMsg{
+			stats: stats,
+		}
+	}
+}
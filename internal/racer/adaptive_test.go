@@ -0,0 +1,50 @@
+package racer
+
+import (
+	"testing"
+	"github.com/arjunmoola/go-racer/internal/engine"
+)
+
+func TestWordWeights(t *testing.T) {
+	stats := engine.NewKeyStats()
+	stats.Chars['q'] = &engine.KeyStat{ Attempts: 10, Errors: 10 }
+	stats.Chars['a'] = &engine.KeyStat{ Attempts: 100 }
+	stats.Bigrams["qu"] = &engine.KeyStat{ Attempts: 10, Errors: 5 }
+
+	weights := wordWeights([]string{ "aaa", "quiz", "aq" }, stats, 4)
+
+	if weights[0] != 1 {
+		t.Errorf("weight of a word without mistakes got %v wanted 1", weights[0])
+	}
+
+	// q is half mistyped and qu a quarter once smoothed
+	if want := 1 + 4*(0.5 + 0.25); weights[1] != want {
+		t.Errorf("weight of quiz got %v wanted %v", weights[1], want)
+	}
+
+	if weights[2] != 3 {
+		t.Errorf("weight of aq got %v wanted 3", weights[2])
+	}
+
+	// the weights decay back to one as the keys are typed correctly
+	stats.Chars['q'].Attempts = 1000
+	stats.Bigrams["qu"].Attempts = 1000
+
+	if weights := wordWeights([]string{ "quiz" }, stats, 4); weights[0] > 1.1 {
+		t.Errorf("weight of quiz after improving got %v", weights[0])
+	}
+}
+
+func TestWeightedSampler(t *testing.T) {
+	sampler := weightedSampler([]float64{ 1, 0, 3 })
+	rng := newRand(7)
+	counts := make([]int, 3)
+
+	for range 4000 {
+		counts[sampler(rng)]++
+	}
+
+	if counts[1] != 0 || counts[2] < 2*counts[0] {
+		t.Errorf("weighted samples got %v wanted about 1000, 0 and 3000", counts)
+	}
+}
@@ -10,7 +10,7 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-func RunAddTest(args []string) error {
+func RunAddTest(paths Paths, args []string) error {
 	var inputFile string
 	var inputDirectory string
 
@@ -35,24 +35,31 @@ func RunAddTest(args []string) error {
 		os.Exit(1)
 	}
 
-	if err := createDataDirIfNotExist(); err != nil {
+	config, err := ReadOrCreateConfig(paths)
+
+	if err != nil {
+		return err
+	}
+
+	wordsDir := config.paths.WordsDir
+
+	if err := createDataDirIfNotExist(wordsDir); err != nil {
 		return err
 	}
 
 	if inputFile != "" {
-		return processInputFile(inputFile)
+		return processInputFile(wordsDir, inputFile)
 	}
 
 	if inputDirectory != "" {
-		return processInputDirectory(inputDirectory)
+		return processInputDirectory(wordsDir, inputDirectory)
 	}
 
 	return nil
 
 }
 
-func createDataDirIfNotExist() error {
-	dataDir := os.ExpandEnv(defaultDataDir)
+func createDataDirIfNotExist(dataDir string) error {
 	_, err := os.Lstat(dataDir)
 
 	dirNotFound := false
@@ -103,8 +110,8 @@ func verifyWordList(wordList *WordList) error {
 	return nil
 }
 
-func saveWordList(wordList *WordList) error {
-	path := filepath.Join(os.ExpandEnv(defaultDataDir), wordList.Name + ".json")
+func saveWordList(dir string, wordList *WordList) error {
+	path := filepath.Join(dir, wordList.Name + ".json")
 
 	file, err := os.Create(path)
 
@@ -121,7 +128,7 @@ func saveWordList(wordList *WordList) error {
 	return nil
 }
 
-func processInputFile(inputFile string) error {
+func processInputFile(wordsDir, inputFile string) error {
 	wordList, err := readInputFile(inputFile)
 
 	if err != nil {
@@ -132,14 +139,14 @@ func processInputFile(inputFile string) error {
 		return err
 	}
 
-	if err := saveWordList(wordList); err != nil {
+	if err := saveWordList(wordsDir, wordList); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func processInputDirectory(dir string) error {
+func processInputDirectory(wordsDir, dir string) error {
 	dirEntries, err := os.ReadDir(dir)
 
 	if err != nil {
@@ -150,7 +157,7 @@ func processInputDirectory(dir string) error {
 
 	for _, entry := range dirEntries {
 		g.Go(func() error {
-			return processInputFile(filepath.Join(dir, entry.Name()))
+			return processInputFile(wordsDir, filepath.Join(dir, entry.Name()))
 		})
 	}
 
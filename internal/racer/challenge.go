@@ -0,0 +1,304 @@
+package racer
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// version 2 added the sampling strategy and lazy mode, codes of version 1
+// are still accepted and sample uniformly.
+const challengeVersion = 2
+
+var ErrInvalidChallenge = errors.New("invalid challenge code")
+
+// the position of a mode in this list is part of the code format, so new
+// modes must only ever be appended.
+var challengeModes = []string{ "time", "words", "quote" }
+
+const (
+	challengeBackspace = 1 << iota
+	challengePunctuation
+	challengeNumbers
+	challengeLazy
+	challengeStopOnLetter
+	challengeStopOnWord
+)
+
+// Challenge describes a test completely enough that everyone who enters
+// its code types exactly the same text. Size is the number of words that
+// are generated and QuoteId picks the quote in quote mode. SamplingSize is
+// the parameter of the sampling strategy, the number of words of top and
+// the window of no repeat.
+type Challenge struct {
+	TestName string
+	Mode string
+	Duration int
+	Size int
+	QuoteId int
+	AllowBackspace bool
+	Punctuation bool
+	Numbers bool
+	Sampling string
+	SamplingSize int
+	Lazy bool
+	StopOnError string
+	Seed uint64
+}
+
+// challengeFromConfig creates a challenge for the next test the config
+// describes with a new seed.
+func challengeFromConfig(config *Config2) *Challenge {
+	c := &Challenge{
+		TestName: config.TestName,
+		Mode: config.GameMode,
+		Duration: config.TestDuration,
+		Size: config.TestSize,
+		AllowBackspace: config.AllowBackspace,
+		Punctuation: config.Punctuation,
+		Numbers: config.Numbers,
+		Sampling: config.Sampling,
+		SamplingSize: samplingSize(config, config.Sampling),
+		Lazy: config.LazyMode,
+		StopOnError: config.StopOnError,
+		Seed: newSeed(config),
+	}
+
+	switch c.Mode {
+	case "words":
+		c.Size = config.WordsTestSize
+	case "quote":
+		c.TestName = config.QuoteList
+		c.Size = 0
+		c.Punctuation = false
+		c.Numbers = false
+		c.Sampling = samplingUniform
+		c.SamplingSize = 0
+		c.Lazy = false
+	}
+
+	return c
+}
+
+// Code encodes the challenge into a string that is safe to paste into chat
+// messages and command lines.
+func (c *Challenge) Code() string {
+	var flags byte
+
+	if c.AllowBackspace {
+		flags |= challengeBackspace
+	}
+
+	if c.Punctuation {
+		flags |= challengePunctuation
+	}
+
+	if c.Numbers {
+		flags |= challengeNumbers
+	}
+
+	if c.Lazy {
+		flags |= challengeLazy
+	}
+
+	switch c.StopOnError {
+	case "letter":
+		flags |= challengeStopOnLetter
+	case "word":
+		flags |= challengeStopOnWord
+	}
+
+	sampling := max(slices.Index(samplingOptions, c.Sampling), 0)
+
+	buf := []byte{ challengeVersion, byte(slices.Index(challengeModes, c.Mode)), flags, byte(sampling) }
+	buf = binary.AppendUvarint(buf, uint64(c.Duration))
+	buf = binary.AppendUvarint(buf, uint64(c.Size))
+	buf = binary.AppendUvarint(buf, uint64(c.QuoteId))
+	buf = binary.AppendUvarint(buf, c.Seed)
+	buf = binary.AppendUvarint(buf, uint64(c.SamplingSize))
+	buf = append(buf, c.TestName...)
+
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func readUvarint(buf []byte) (uint64, []byte, error) {
+	n, size := binary.Uvarint(buf)
+
+	if size <= 0 {
+		return 0, nil, ErrInvalidChallenge
+	}
+
+	return n, buf[size:], nil
+}
+
+// DecodeChallenge parses a code created by Challenge.Code.
+func DecodeChallenge(code string) (*Challenge, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(code))
+
+	if err != nil || len(buf) < 3 {
+		return nil, ErrInvalidChallenge
+	}
+
+	version := buf[0]
+
+	if version < 1 || version > challengeVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidChallenge, version)
+	}
+
+	if int(buf[1]) >= len(challengeModes) {
+		return nil, ErrInvalidChallenge
+	}
+
+	c := &Challenge{
+		Mode: challengeModes[buf[1]],
+		AllowBackspace: buf[2]&challengeBackspace != 0,
+		Punctuation: buf[2]&challengePunctuation != 0,
+		Numbers: buf[2]&challengeNumbers != 0,
+		Lazy: buf[2]&challengeLazy != 0,
+		Sampling: samplingUniform,
+		StopOnError: defaultStopOnError,
+	}
+
+	switch buf[2] & (challengeStopOnLetter | challengeStopOnWord) {
+	case challengeStopOnLetter:
+		c.StopOnError = "letter"
+	case challengeStopOnWord:
+		c.StopOnError = "word"
+	case challengeStopOnLetter | challengeStopOnWord:
+		return nil, ErrInvalidChallenge
+	}
+
+	fields := make([]uint64, 4)
+	rest := buf[3:]
+
+	if version >= 2 {
+		if len(rest) == 0 || int(rest[0]) >= len(samplingOptions) {
+			return nil, ErrInvalidChallenge
+		}
+
+		c.Sampling = samplingOptions[rest[0]]
+		rest = rest[1:]
+		fields = append(fields, 0)
+	}
+
+	for i := range fields {
+		fields[i], rest, err = readUvarint(rest)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.Duration = int(fields[0])
+	c.Size = int(fields[1])
+	c.QuoteId = int(fields[2])
+	c.Seed = fields[3]
+	c.TestName = string(rest)
+
+	if version >= 2 {
+		c.SamplingSize = int(fields[4])
+	}
+
+	if c.TestName == "" {
+		return nil, ErrInvalidChallenge
+	}
+
+	switch c.Mode {
+	case "time":
+		if c.Duration <= 0 || c.Size <= 0 {
+			return nil, ErrInvalidChallenge
+		}
+	case "words":
+		if c.Size <= 0 {
+			return nil, ErrInvalidChallenge
+		}
+	case "quote":
+		if c.QuoteId <= 0 {
+			return nil, ErrInvalidChallenge
+		}
+	}
+
+	return c, nil
+}
+
+// challenge describes the test currently loaded in the game.
+func (g *Game) challenge() *Challenge {
+	c := &Challenge{
+		TestName: g.testName,
+		Mode: g.mode,
+		Duration: g.testDuration,
+		Size: g.testSize,
+		QuoteId: g.quoteId(),
+		AllowBackspace: g.allowBackspace,
+		Punctuation: g.punctuation,
+		Numbers: g.numbers,
+		Sampling: g.sampling,
+		SamplingSize: g.samplingSize,
+		Lazy: g.lazy,
+		StopOnError: g.stopOnError,
+		Seed: g.seed,
+	}
+
+	if g.mode == "words" {
+		c.Size = g.wordsTestSize
+	}
+
+	return c
+}
+
+// SetChallenge makes the next test the one described by code. The word or
+// quote list the challenge uses has to be installed.
+func (r *RacerModel) SetChallenge(code string) error {
+	c, err := DecodeChallenge(code)
+
+	if err != nil {
+		return err
+	}
+
+	if c.Mode == "quote" {
+		l, ok := r.quoteDb.Get(c.TestName)
+
+		if !ok {
+			return fmt.Errorf("quote list %s is not installed", c.TestName)
+		}
+
+		if _, ok := l.GetById(c.QuoteId); !ok {
+			return fmt.Errorf("quote %d not found in %s", c.QuoteId, c.TestName)
+		}
+	} else if !r.wordDb.Contains(c.TestName) {
+		return fmt.Errorf("word list %s is not installed", c.TestName)
+	}
+
+	r.challenge = c
+	r.ghost = nil
+	r.ghostTestId = 0
+	r.game.Reset()
+	r.SetState(GAME)
+
+	return nil
+}
+
+// ChallengeInputModel is the form a challenge code is pasted into.
+type ChallengeInputModel struct {
+	input []rune
+	err error
+}
+
+func (m *ChallengeInputModel) render() string {
+	builder := &strings.Builder{}
+
+	builder.WriteString("Enter challenge code:\n")
+	builder.WriteString(inputStyle.Render(string(m.input)))
+	builder.WriteRune('\n')
+
+	if m.err != nil {
+		fmt.Fprintf(builder, "%v\n", m.err)
+	}
+
+	builder.WriteString("press enter to start the challenge\n")
+	builder.WriteString("press esc to go back to main menu\n")
+	return builder.String()
+}
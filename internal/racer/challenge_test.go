@@ -0,0 +1,124 @@
+package racer
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestChallengeCode(t *testing.T) {
+	tests := []*Challenge{
+		{ TestName: "english_10k", Mode: "time", Duration: 30, Size: 200, AllowBackspace: true, Sampling: "uniform", StopOnError: "letter", Seed: 1<<63 + 12345 },
+		{ TestName: "русский", Mode: "words", Size: 25, Punctuation: true, Numbers: true, Sampling: "top", SamplingSize: 500, StopOnError: "off", Seed: 7 },
+		{ TestName: "français", Mode: "words", Size: 25, Sampling: "no repeat", SamplingSize: 10, Lazy: true, StopOnError: "word", Seed: 9 },
+		{ TestName: "english", Mode: "quote", QuoteId: 3, Sampling: "uniform", StopOnError: "off" },
+	}
+
+	for _, test := range tests {
+		code := test.Code()
+		got, err := DecodeChallenge(" " + code + "\n")
+
+		if err != nil {
+			t.Errorf("could not decode %s: %v", code, err)
+			continue
+		}
+
+		if *got != *test {
+			t.Errorf("challenge changed in round trip got %+v wanted %+v", got, test)
+		}
+	}
+}
+
+func TestDecodeChallengeVersion1(t *testing.T) {
+	buf := []byte{ 1, 1, challengePunctuation, 0, 10, 0, 7 }
+	buf = append(buf, "english"...)
+
+	got, err := DecodeChallenge(base64.RawURLEncoding.EncodeToString(buf))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Challenge{ TestName: "english", Mode: "words", Size: 10, Punctuation: true, Sampling: "uniform", StopOnError: "off", Seed: 7 }
+
+	if *got != want {
+		t.Errorf("version 1 code got %+v wanted %+v", got, want)
+	}
+}
+
+func TestDecodeInvalidChallenge(t *testing.T) {
+	valid := (&Challenge{ TestName: "english_10k", Mode: "words", Size: 10, Seed: 1 }).Code()
+
+	// Code never sets both stop modes
+	buf, _ := base64.RawURLEncoding.DecodeString(valid)
+	buf[2] |= challengeStopOnLetter | challengeStopOnWord
+	bothStopModes := base64.RawURLEncoding.EncodeToString(buf)
+
+	codes := []string{
+		"",
+		"not a code!",
+		valid[:4],
+		(&Challenge{ TestName: "english_10k", Mode: "words", Seed: 1 }).Code(),
+		(&Challenge{ TestName: "", Mode: "words", Size: 10, Seed: 1 }).Code(),
+		(&Challenge{ TestName: "english", Mode: "quote" }).Code(),
+		bothStopModes,
+	}
+
+	for _, code := range codes {
+		if _, err := DecodeChallenge(code); !errors.Is(err, ErrInvalidChallenge) {
+			t.Errorf("DecodeChallenge(%q) got error %v wanted %v", code, err, ErrInvalidChallenge)
+		}
+	}
+}
+
+func TestChallengeReproducesTest(t *testing.T) {
+	words := &WordList{
+		Name: "test",
+		Words: []string{ "alpha", "bravo", "charlie", "delta", "echo", "foxtrot" },
+	}
+
+	newRacer := func() *RacerModel {
+		config := DefaultConfig2()
+		config.TestName = words.Name
+		config.GameMode = "words"
+		config.WordsTestSize = 15
+		config.Punctuation = true
+
+		r := &RacerModel{
+			config: config,
+			wordDb: &WordDb{ wordLists: map[string]*WordList{ words.Name: words } },
+			quoteDb: &QuoteDb{ quoteLists: map[string]*QuoteList{} },
+		}
+		r.game = NewGameFromConfig(config)
+		r.game.racer = r
+		return r
+	}
+
+	host := newRacer()
+	host.config.Sampling = samplingNoRepeat
+	host.config.SamplingWindow = 3
+	host.game.createTest()
+	code := host.game.challenge().Code()
+
+	guest := newRacer()
+	guest.config.GameMode = "time"
+	guest.config.Punctuation = false
+
+	if err := guest.SetChallenge(code); err != nil {
+		t.Fatal(err)
+	}
+
+	guest.game.createTest()
+
+	if got, want := string(guest.game.target()), string(host.game.target()); got != want {
+		t.Errorf("challenge produced a different test got %q wanted %q", got, want)
+	}
+
+	if guest.game.mode != "words" || !guest.game.punctuation || guest.game.sampling != samplingNoRepeat {
+		t.Errorf("challenge settings not applied got mode %s punctuation %v sampling %s", guest.game.mode, guest.game.punctuation, guest.game.sampling)
+	}
+
+	if err := guest.SetChallenge((&Challenge{ TestName: "missing", Mode: "words", Size: 5 }).Code()); err == nil {
+		t.Errorf("challenge for a missing word list accepted")
+	}
+}
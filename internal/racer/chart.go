@@ -0,0 +1,190 @@
+package racer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws one bar per value scaled between the smallest and the
+// largest value. Only the last width values are drawn.
+func sparkline(values []float64, width int) string {
+	if width > 0 && len(values) > width {
+		values = values[len(values)-width:]
+	}
+
+	if len(values) == 0 {
+		return ""
+	}
+
+	lo, hi := slices.Min(values), slices.Max(values)
+	builder := &strings.Builder{}
+
+	for _, v := range values {
+		idx := len(sparkRunes) - 1
+
+		if hi > lo {
+			idx = int((v - lo)/(hi - lo)*float64(len(sparkRunes) - 1) + 0.5)
+		}
+
+		builder.WriteRune(sparkRunes[idx])
+	}
+
+	return builder.String()
+}
+
+// barline is a sparkline that starts at zero so empty values are drawn as
+// gaps, which suits counts.
+func barline(values []int) string {
+	hi := 0
+
+	for _, v := range values {
+		hi = max(hi, v)
+	}
+
+	builder := &strings.Builder{}
+
+	for _, v := range values {
+		if v == 0 || hi == 0 {
+			builder.WriteRune(' ')
+			continue
+		}
+
+		idx := (v*len(sparkRunes) - 1)/hi
+		builder.WriteRune(sparkRunes[idx])
+	}
+
+	return builder.String()
+}
+
+// chartSeries is one line of a lineChart. mark is drawn, already styled,
+// in every column.
+type chartSeries struct {
+	values []float64
+	mark string
+}
+
+// resample shrinks values to at most width values by merging neighbouring
+// values with merge.
+func resample[T any](values []T, width int, merge func([]T) T) []T {
+	if width <= 0 || len(values) <= width {
+		return values
+	}
+
+	out := make([]T, width)
+
+	for i := range out {
+		out[i] = merge(values[i*len(values)/width:(i + 1)*len(values)/width])
+	}
+
+	return out
+}
+
+func meanOf(values []float64) float64 {
+	var sum float64
+
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum/float64(len(values))
+}
+
+func sumOf(values []int) int {
+	var sum int
+
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum
+}
+
+// lineChart draws the series from zero to their largest value over height
+// rows with one column per value, later series are drawn over earlier ones.
+// Columns with errors are marked with errorMark in a row below the chart.
+// The chart is resampled to fit into width columns.
+func lineChart(series []chartSeries, errors []int, height int, width int, errorMark string) string {
+	columns := len(errors)
+	hi := 0.0
+
+	for _, s := range series {
+		columns = max(columns, len(s.values))
+
+		for _, v := range s.values {
+			hi = max(hi, v)
+		}
+	}
+
+	if columns == 0 || height <= 0 {
+		return ""
+	}
+
+	label := len(fmt.Sprintf("%.0f", hi))
+	width -= label + 2
+	seconds := columns
+	columns = min(columns, max(width, 1))
+
+	grid := make([][]string, height)
+
+	for i := range grid {
+		grid[i] = slices.Repeat([]string{ " " }, columns)
+	}
+
+	for _, s := range series {
+		for x, v := range resample(s.values, columns, meanOf) {
+			y := 0
+
+			if hi > 0 {
+				y = int(math.Round(v/hi*float64(height - 1)))
+			}
+
+			grid[height - 1 - y][x] = s.mark
+		}
+	}
+
+	builder := &strings.Builder{}
+
+	for i, row := range grid {
+		var value string
+
+		switch i {
+		case 0:
+			value = fmt.Sprintf("%.0f", hi)
+		case height - 1:
+			value = "0"
+		}
+
+		fmt.Fprintf(builder, "%*s ┤%s\n", label, value, strings.Join(row, ""))
+	}
+
+	fmt.Fprintf(builder, "%*s └%s\n", label, "", strings.Repeat("─", columns))
+
+	if sumOf(errors) > 0 {
+		builder.WriteString(strings.Repeat(" ", label + 2))
+
+		for _, n := range resample(errors, columns, sumOf) {
+			if n > 0 {
+				builder.WriteString(errorMark)
+			} else {
+				builder.WriteRune(' ')
+			}
+		}
+
+		builder.WriteRune('\n')
+	}
+
+	first, last := "1s", fmt.Sprintf("%ds", seconds)
+	axis := first
+
+	if seconds > 1 {
+		axis += strings.Repeat(" ", max(columns - len(first) - len(last), 1)) + last
+	}
+
+	fmt.Fprintf(builder, "%*s  %s\n", label, "", axis)
+
+	return builder.String()
+}
@@ -0,0 +1,40 @@
+package racer
+
+import (
+	"math/rand/v2"
+	"time"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Clock is the source of time for a game. Every timestamp and tick the
+// game uses goes through it so tests can decide how much time passes.
+type Clock interface {
+	Now() time.Time
+	Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+	return tea.Tick(d, fn)
+}
+
+// newSeed picks the seed of a test. A seed set in the config is used for
+// every test so that everyone using it types the same words.
+func newSeed(config *Config2) uint64 {
+	if config.Seed != 0 {
+		return config.Seed
+	}
+
+	return rand.Uint64()
+}
+
+// newRand returns the random source a test with the given seed draws its
+// words from. The same seed always produces the same test.
+func newRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
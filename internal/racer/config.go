@@ -15,16 +15,11 @@ var (
 	ErrInvalidConfigKey = errors.New("invalid config key")
 )
 
-var (
-	defaultConfigDir = os.ExpandEnv("$HOME/.go-racer")
-	defaultConfigPath = filepath.Join(defaultConfigDir, "config.json")
-	defaultDataDir = filepath.Join(defaultConfigDir, "data")
-)
-
 const (
 	defaultWindowSize = 3
 	defaultGameMode = "time"
 	defaultNumWordsPerLine = 20
+	defaultMaxLineWidth = 80
 	defaultAllowBackspace = false
 	defaultTestName = "english"
 	defaultTestDuration = 30
@@ -36,6 +31,10 @@ const (
 	defaultLineSpacing = 2
 	defaultCursorColor = "32"
 	defaultOverlapSpaceColor = "#A9A9A9"
+	defaultQuoteList = "english"
+	defaultQuoteLength = "all"
+	defaultGhost = "off"
+	defaultGhostColor = "#5f5faf"
 )
 
 type Config struct {
@@ -57,17 +56,54 @@ type Config2 struct {
 	TestDuration int `toml:"testDuration"`
 	GameMode string `toml:"gameMode"`
 	AllowBackspace bool `toml:"allowBackspace"`
+	Punctuation bool `toml:"punctuation"`
+	Numbers bool `toml:"numbers"`
 	WindowSize int `toml:"windowSize"`
 	NumWordsPerLine int `toml:"numWordsPerLine"`
+	MaxLineWidth int `toml:"maxLineWidth"`
 	TestSize int `toml:"testSize"`
 	WordsTestSize int `json:"wordsTestSize"`
-	data string `json:"-"`
 	MatchColor string `toml:"matchColor"`
 	MismatchColor string `toml:"mismatchColor"`
 	DefaultColor string `toml:"defaultColor"`
 	CursorColor string `toml:"cursorColor"`
 	LineSpacing int `toml:"lineSpacing"`
 	OverlapSpaceColor string `toml:"overlapSpaceColor"`
+	QuoteList string `toml:"quoteList"`
+	QuoteLength string `toml:"quoteLength"`
+	Ghost string `toml:"ghost"`
+	GhostTestId int `toml:"ghostTestId"`
+	GhostColor string `toml:"ghostColor"`
+	// Adaptive is the intensity with which tests favour words containing
+	// the weakest keys and bigrams, off disables adaptive tests.
+	Adaptive string `toml:"adaptive"`
+	// Sampling is how the words of a test are drawn from the word list:
+	// uniform, zipf favouring the frequent words, top drawing only from the
+	// SamplingTopN most frequent words or no repeat which keeps a word from
+	// coming back within SamplingWindow words. Zipf and top fall back to
+	// uniform for lists that are not ordered by frequency.
+	Sampling string `toml:"sampling"`
+	SamplingTopN int `toml:"samplingTopN"`
+	SamplingWindow int `toml:"samplingWindow"`
+	// LazyMode strips the diacritics from the words of lists that allow it.
+	LazyMode bool `toml:"lazyMode"`
+	// StopOnError is off, letter to keep the cursor from moving past a
+	// mistyped character or word to keep it from moving past a word with a
+	// mistake in it.
+	StopOnError string `toml:"stopOnError"`
+	// KeyboardLayoutName picks the keyboard drawn by the key statistics,
+	// either a builtin layout or one of Layouts. A layout lists the keys of
+	// each row from the top.
+	KeyboardLayoutName string `toml:"keyboardLayout"`
+	Layouts map[string][]string `toml:"layouts,omitempty"`
+	// Seed fixes the words of every test. Zero picks a new seed each test.
+	Seed uint64 `toml:"seed"`
+	// DataDir, WordsDir and DbPath move the files racer keeps. Empty values
+	// use the locations derived from RACER_HOME or the XDG directories.
+	DataDir string `toml:"dataDir,omitempty"`
+	WordsDir string `toml:"wordsDir,omitempty"`
+	DbPath string `toml:"dbPath,omitempty"`
+	paths Paths
 }
 
 func getHomeDir() (string, error) {
@@ -88,8 +124,17 @@ func (c *Config2) write(w io.Writer) error {
 	return toml.NewEncoder(w).Encode(c)
 }
 
+// Paths returns the locations the config was resolved to.
+func (c *Config2) Paths() Paths {
+	return c.paths
+}
+
 func (c *Config2) Save() error {
-	file, err := os.Create(filepath.Join(defaultConfigDir, "config.toml"))
+	if err := ensureParentDir(c.paths.ConfigFile); err != nil {
+		return err
+	}
+
+	file, err := os.Create(c.paths.ConfigFile)
 
 	if err != nil {
 		return err
@@ -100,14 +145,13 @@ func (c *Config2) Save() error {
 	return c.write(file)
 }
 
-func ReadConfigFile2() (*Config2, error) {
-	configFilePath := filepath.Join(defaultConfigDir, "config.toml")
-	
+func ReadConfigFile2(configFilePath string) (*Config2, error) {
 	_, err := os.Lstat(configFilePath)
 
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			config := DefaultConfig2()
+			config.paths.ConfigFile = configFilePath
 			if err := config.Save(); err != nil {
 				return nil, err
 			}
@@ -117,7 +161,7 @@ func ReadConfigFile2() (*Config2, error) {
 		}
 	}
 
-	file, err := os.Open(filepath.Join(defaultConfigDir, "config.toml"))
+	file, err := os.Open(configFilePath)
 
 	if err != nil {
 		return nil, err
@@ -131,7 +175,7 @@ func ReadConfigFile2() (*Config2, error) {
 		return nil, err
 	}
 
-	config.data = defaultDataDir
+	config.paths.ConfigFile = configFilePath
 
 	if config.TestName == "" {
 		config.TestName = defaultTestName
@@ -153,6 +197,10 @@ func ReadConfigFile2() (*Config2, error) {
 		config.NumWordsPerLine = defaultNumWordsPerLine
 	}
 
+	if config.MaxLineWidth <= 0 {
+		config.MaxLineWidth = defaultMaxLineWidth
+	}
+
 	if config.TestSize <= 0 {
 		config.TestSize = defaultTestSize
 	}
@@ -185,12 +233,52 @@ func ReadConfigFile2() (*Config2, error) {
 		config.OverlapSpaceColor = defaultOverlapSpaceColor
 	}
 
+	if config.QuoteList == "" {
+		config.QuoteList = defaultQuoteList
+	}
+
+	if config.QuoteLength == "" {
+		config.QuoteLength = defaultQuoteLength
+	}
+
+	if config.Ghost == "" {
+		config.Ghost = defaultGhost
+	}
+
+	if config.GhostColor == "" {
+		config.GhostColor = defaultGhostColor
+	}
+
+	if config.Adaptive == "" {
+		config.Adaptive = defaultAdaptive
+	}
+
+	if config.KeyboardLayoutName == "" {
+		config.KeyboardLayoutName = defaultKeyboardLayout
+	}
+
+	if config.Sampling == "" {
+		config.Sampling = defaultSampling
+	}
+
+	if config.StopOnError == "" {
+		config.StopOnError = defaultStopOnError
+	}
+
+	if config.SamplingTopN <= 0 {
+		config.SamplingTopN = defaultSamplingTopN
+	}
+
+	if config.SamplingWindow <= 0 {
+		config.SamplingWindow = defaultSamplingWindow
+	}
+
 	return &config, nil
 }
 
 
 func ReadConfigFile() (*Config, error) {
-	file, err := os.Open(defaultConfigPath)
+	file, err := os.Open(legacyConfigPath)
 
 	if err != nil {
 		return nil, err
@@ -204,7 +292,7 @@ func ReadConfigFile() (*Config, error) {
 		return nil, err
 	}
 
-	config.data = defaultDataDir
+	config.data = legacyDataDir
 
 	if config.Words == "" {
 		config.Words = defaultTestName
@@ -246,7 +334,7 @@ func (c *Config) write(w io.Writer) error {
 }
 
 func (c *Config) Save() error {
-	file, err := os.Create(defaultConfigPath)
+	file, err := os.Create(legacyConfigPath)
 
 	if err != nil {
 		return err
@@ -262,7 +350,7 @@ func DefaultConfig() *Config {
 		Words: defaultTestName,
 		Time: defaultTestDuration,
 		GameMode: defaultGameMode,
-		data: defaultDataDir,
+		data: legacyDataDir,
 		NumWordsPerLine: defaultNumWordsPerLine,
 		WindowSize: defaultWindowSize,
 		AllowBackspace: defaultAllowBackspace,
@@ -276,8 +364,8 @@ func DefaultConfig2() *Config2 {
 		TestName: defaultTestName,
 		TestDuration: defaultTestDuration,
 		GameMode: defaultGameMode,
-		data: defaultDataDir,
 		NumWordsPerLine: defaultNumWordsPerLine,
+		MaxLineWidth: defaultMaxLineWidth,
 		WindowSize: defaultWindowSize,
 		AllowBackspace: defaultAllowBackspace,
 		TestSize: defaultTestSize,
@@ -288,17 +376,27 @@ func DefaultConfig2() *Config2 {
 		LineSpacing: defaultLineSpacing,
 		DefaultColor: defaultColor,
 		OverlapSpaceColor: defaultOverlapSpaceColor,
+		QuoteList: defaultQuoteList,
+		QuoteLength: defaultQuoteLength,
+		Ghost: defaultGhost,
+		GhostColor: defaultGhostColor,
+		KeyboardLayoutName: defaultKeyboardLayout,
+		Adaptive: defaultAdaptive,
+		Sampling: defaultSampling,
+		SamplingTopN: defaultSamplingTopN,
+		SamplingWindow: defaultSamplingWindow,
+		StopOnError: defaultStopOnError,
 	}
 }
 
 func initializeConfigDir() (*Config2, error) {
-	if err := os.Mkdir(defaultConfigDir, 0777); err != nil {
+	if err := os.Mkdir(legacyConfigDir, 0777); err != nil {
 		return nil, err
 	}
 
 	config := DefaultConfig2()
 
-	file, err := os.Create(defaultConfigPath)
+	file, err := os.Create(legacyConfigPath)
 
 	if err != nil {
 		return nil, err
@@ -310,7 +408,7 @@ func initializeConfigDir() (*Config2, error) {
 		return nil, err
 	}
 
-	if err := os.Mkdir(defaultDataDir, 0777); err != nil {
+	if err := os.Mkdir(legacyDataDir, 0777); err != nil {
 		return nil, err
 	}
 
@@ -320,27 +418,51 @@ func initializeConfigDir() (*Config2, error) {
 //go:embed data/*.json
 var testDataFiles embed.FS
 
+//go:embed quotes/*.json
+var quoteDataFiles embed.FS
+
 //go:embed wuxia/intro.txt
 var gameIntroText []byte
 
-func ReadOrCreateConfig() (*Config2, error) {
-	if err := setupConfigDir(); err != nil {
+// ReadOrCreateConfig reads the config file, creating it and the data
+// directories when they do not exist yet. Locations set in flags override
+// both the defaults and the config file.
+func ReadOrCreateConfig(flags Paths) (*Config2, error) {
+	defaults, err := defaultPaths()
+
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := ReadConfigFile2(defaults.merge(flags).ConfigFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.paths = resolvePaths(defaults, config, flags)
+
+	if err := os.MkdirAll(config.paths.DataDir, 0777); err != nil {
 		return nil, err
 	}
 
-	if err := setupDataDir(); err != nil {
+	if err := setupWordsDir(config.paths.WordsDir); err != nil {
 		return nil, err
 	}
 
-	if err := setupWuxiaDir(); err != nil {
+	if err := setupWuxiaDir(config.paths.IntroFile()); err != nil {
 		return nil, err
 	}
 
-	return ReadConfigFile2()
+	if err := setupQuotesDir(config.paths.QuotesDir()); err != nil {
+		return nil, err
+	}
+
+	return config, nil
 }
 
-func setupConfigDir() error {
-	dirExists, err := checkIfDirExists(defaultConfigDir)
+func setupWordsDir(dir string) error {
+	dirExists, err := checkIfDirExists(dir)
 
 	if err != nil {
 		return err
@@ -350,17 +472,36 @@ func setupConfigDir() error {
 		return nil
 	}
 
-	if err := os.Mkdir(defaultConfigDir, 0777); err != nil {
+	if err := os.MkdirAll(dir, 0777); err != nil {
 		return err
 	}
 
-	config := DefaultConfig2()
+	entries, err := testDataFiles.ReadDir("data")
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		data, err := testDataFiles.ReadFile(filepath.Join("data", entry.Name()))
+
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		if err := writeTestDataFiles(path, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
 
-	return config.Save()
 }
 
-func setupDataDir() error {
-	dirExists, err := checkIfDirExists(defaultDataDir)
+func setupQuotesDir(dir string) error {
+	dirExists, err := checkIfDirExists(dir)
 
 	if err != nil {
 		return err
@@ -370,24 +511,24 @@ func setupDataDir() error {
 		return nil
 	}
 
-	if err := os.Mkdir(defaultDataDir, 0777); err != nil {
+	if err := os.MkdirAll(dir, 0777); err != nil {
 		return err
 	}
 
-	entries, err := testDataFiles.ReadDir("data")
+	entries, err := quoteDataFiles.ReadDir("quotes")
 
 	if err != nil {
 		return err
 	}
 
 	for _, entry := range entries {
-		data, err := testDataFiles.ReadFile(filepath.Join("data", entry.Name()))
+		data, err := quoteDataFiles.ReadFile(filepath.Join("quotes", entry.Name()))
 
 		if err != nil {
 			return err
 		}
 
-		path := filepath.Join(defaultDataDir, entry.Name())
+		path := filepath.Join(dir, entry.Name())
 
 		if err := writeTestDataFiles(path, data); err != nil {
 			return err
@@ -395,11 +536,10 @@ func setupDataDir() error {
 	}
 
 	return nil
-
 }
 
-func setupWuxiaDir() error {
-	dir := filepath.Join(defaultConfigDir, "wuxia")
+func setupWuxiaDir(introFile string) error {
+	dir := filepath.Dir(introFile)
 
 	dirExists, err := checkIfDirExists(dir)
 
@@ -411,11 +551,11 @@ func setupWuxiaDir() error {
 		return nil
 	}
 
-	if err := os.Mkdir(dir, 0777); err != nil {
+	if err := os.MkdirAll(dir, 0777); err != nil {
 		return err
 	}
 
-	if err := writeIntroTextData(gameIntroText); err != nil {
+	if err := writeIntroTextData(introFile, gameIntroText); err != nil {
 		return err
 	}
 
@@ -450,9 +590,7 @@ func writeTestDataFiles(path string, data []byte) error {
 	return nil
 }
 
-func writeIntroTextData(data []byte) error {
-	path := filepath.Join(defaultConfigDir, "wuxia", "intro.txt")
-
+func writeIntroTextData(path string, data []byte) error {
 	file, err := os.Create(path)
 
 	if err != nil {
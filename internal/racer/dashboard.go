@@ -0,0 +1,400 @@
+package racer
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	dashboardTrendSize = 100
+	dashboardDays = 14
+)
+
+// StatsFilter limits the tests the statistics are computed from. Empty
+// fields match every test.
+type StatsFilter struct {
+	TestName string
+	Mode string
+	// Since and Until limit the time the tests were taken in, Until is
+	// exclusive.
+	Since time.Time
+	Until time.Time
+	AllowBackspace *bool
+}
+
+// where returns the condition and arguments matching the filter. The
+// condition is always valid after a WHERE.
+func (f *StatsFilter) where() (string, []any) {
+	conds := []string{ "true" }
+	var args []any
+
+	if f.TestName != "" {
+		conds = append(conds, "test_name = ?")
+		args = append(args, f.TestName)
+	}
+
+	if f.Mode != "" {
+		conds = append(conds, "mode = ?")
+		args = append(args, f.Mode)
+	}
+
+	if !f.Since.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, f.Since)
+	}
+
+	if !f.Until.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, f.Until)
+	}
+
+	if f.AllowBackspace != nil {
+		conds = append(conds, "allow_backspace = ?")
+		args = append(args, *f.AllowBackspace)
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+func (f *StatsFilter) matches(test *RacerTest) bool {
+	return (f.TestName == "" || f.TestName == test.Test) &&
+		(f.Mode == "" || f.Mode == test.Mode) &&
+		(f.Since.IsZero() || !test.CreatedAt.Before(f.Since)) &&
+		(f.Until.IsZero() || test.CreatedAt.Before(f.Until)) &&
+		(f.AllowBackspace == nil || *f.AllowBackspace == test.AllowBackspace)
+}
+
+// Average is the mean wpm and accuracy of a number of tests.
+type Average struct {
+	Tests int
+	Wpm float64
+	Accuracy float64
+}
+
+// DayCount is the number of tests taken on a day.
+type DayCount struct {
+	Day time.Time
+	Tests int
+}
+
+// Dashboard summarizes the test history. The trends hold the wpm and accuracy
+// of the most recent tests oldest first and Days the tests taken on each
+// of the last days, including days without tests.
+type Dashboard struct {
+	Tests int
+	TimeTyped time.Duration
+	Last10 Average
+	Last100 Average
+	WpmTrend []float64
+	AccuracyTrend []float64
+	Days []DayCount
+}
+
+// the seconds typed in a test. Every second has a sample, imported tests
+// have none and only know their duration in time mode.
+const testSecondsExpr = "coalesce(nullif(len(wpm_samples), 0), CASE WHEN mode = 'time' THEN test_duration END, 0)"
+
+const dashboardSummaryQueryStr = `
+	WITH recent AS (
+		SELECT *, row_number() OVER (ORDER BY created_at DESC, id DESC) AS n
+		FROM all_tests
+		WHERE %s
+	)
+	SELECT
+		count(*),
+		coalesce(sum(%s), 0),
+		count(*) FILTER (WHERE n <= 10),
+		coalesce(avg(wpm) FILTER (WHERE n <= 10), 0),
+		coalesce(avg(accuracy) FILTER (WHERE n <= 10), 0),
+		count(*) FILTER (WHERE n <= 100),
+		coalesce(avg(wpm) FILTER (WHERE n <= 100), 0),
+		coalesce(avg(accuracy) FILTER (WHERE n <= 100), 0)
+	FROM recent
+	`
+
+const dashboardTrendQueryStr = `
+	SELECT wpm, accuracy
+	FROM (
+		SELECT id, created_at, wpm, accuracy
+		FROM all_tests
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT %d
+	)
+	ORDER BY created_at, id
+	`
+
+// the tests are bucketed into days by addDayCount rather than by the
+// database, which does not know the local time zone.
+const dashboardDaysQueryStr = `
+	SELECT created_at
+	FROM all_tests
+	WHERE %s AND created_at >= ?
+	`
+
+// dashboardDayRange returns the days the dashboard counts tests for, oldest
+// first, ending with the day of now. Days start at midnight in the location
+// of now, like the date filter of the history and the review summary.
+func dashboardDayRange(now time.Time) []DayCount {
+	today := startOfDay(now)
+	days := make([]DayCount, dashboardDays)
+
+	for i := range days {
+		days[i].Day = today.AddDate(0, 0, i - dashboardDays + 1)
+	}
+
+	return days
+}
+
+func addDayCount(days []DayCount, day time.Time, count int) {
+	day = day.In(days[0].Day.Location())
+
+	for i := range days {
+		d := days[i].Day
+
+		if d.Year() == day.Year() && d.YearDay() == day.YearDay() {
+			days[i].Tests += count
+			return
+		}
+	}
+}
+
+// GetDashboard computes the dashboard for the tests matching filter with
+// aggregate queries.
+func GetDashboard(db *sql.DB, filter *StatsFilter, now time.Time) (*Dashboard, error) {
+	where, args := filter.where()
+	d := &Dashboard{}
+	var seconds int64
+
+	row := db.QueryRow(fmt.Sprintf(dashboardSummaryQueryStr, where, testSecondsExpr), args...)
+
+	err := row.Scan(
+		&d.Tests,
+		&seconds,
+		&d.Last10.Tests,
+		&d.Last10.Wpm,
+		&d.Last10.Accuracy,
+		&d.Last100.Tests,
+		&d.Last100.Wpm,
+		&d.Last100.Accuracy,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.TimeTyped = time.Duration(seconds)*time.Second
+
+	rows, err := db.Query(fmt.Sprintf(dashboardTrendQueryStr, where, dashboardTrendSize), args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var wpm int
+		var accuracy float64
+
+		if err := rows.Scan(&wpm, &accuracy); err != nil {
+			return nil, err
+		}
+
+		d.WpmTrend = append(d.WpmTrend, float64(wpm))
+		d.AccuracyTrend = append(d.AccuracyTrend, accuracy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	d.Days = dashboardDayRange(now)
+
+	dayRows, err := db.Query(fmt.Sprintf(dashboardDaysQueryStr, where), append(args, d.Days[0].Day)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer dayRows.Close()
+
+	for dayRows.Next() {
+		var createdAt time.Time
+
+		if err := dayRows.Scan(&createdAt); err != nil {
+			return nil, err
+		}
+
+		addDayCount(d.Days, createdAt, 1)
+	}
+
+	if err := dayRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (a *Average) add(test *RacerTest) {
+	a.Tests++
+	a.Wpm += float64(test.Wpm)
+	a.Accuracy += test.Accuracy
+}
+
+func testSeconds(test *RacerTest) int {
+	if len(test.WpmList) > 0 {
+		return len(test.WpmList)
+	}
+
+	if test.Mode == "time" {
+		return test.Time
+	}
+
+	return 0
+}
+
+func (s *MemoryStore) GetDashboard(filter *StatsFilter) (*Dashboard, error) {
+	s.mu.Lock()
+
+	var tests []*RacerTest
+
+	for _, test := range s.tests {
+		if filter.matches(test) {
+			tests = append(tests, test)
+		}
+	}
+
+	now := s.now()
+	s.mu.Unlock()
+
+	// newest first like the queries of GetDashboard
+	slices.SortFunc(tests, func(a, b *RacerTest) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.Id, a.Id))
+	})
+
+	d := &Dashboard{ Tests: len(tests), Days: dashboardDayRange(now) }
+
+	for i, test := range tests {
+		d.TimeTyped += time.Duration(testSeconds(test))*time.Second
+
+		if i < 10 {
+			d.Last10.add(test)
+		}
+
+		if i < 100 {
+			d.Last100.add(test)
+		}
+
+		if i < dashboardTrendSize {
+			d.WpmTrend = append(d.WpmTrend, float64(test.Wpm))
+			d.AccuracyTrend = append(d.AccuracyTrend, test.Accuracy)
+		}
+
+		if !test.CreatedAt.Before(d.Days[0].Day) {
+			addDayCount(d.Days, test.CreatedAt, 1)
+		}
+	}
+
+	for _, avg := range []*Average{ &d.Last10, &d.Last100 } {
+		if avg.Tests > 0 {
+			avg.Wpm /= float64(avg.Tests)
+			avg.Accuracy /= float64(avg.Tests)
+		}
+	}
+
+	slices.Reverse(d.WpmTrend)
+	slices.Reverse(d.AccuracyTrend)
+
+	return d, nil
+}
+
+func (s *DuckDBStore) GetDashboard(filter *StatsFilter) (*Dashboard, error) {
+	return GetDashboard(s.db, filter, time.Now())
+}
+
+// DashboardModel shows the dashboard of the statistics screen and the
+// filters it was computed with.
+type DashboardModel struct {
+	dashboard *Dashboard
+	filter StatsFilter
+	testNames []string
+	err error
+}
+
+var dashboardModes = []string{ "", "time", "words", "quote", reviewMode }
+
+func nextFilterValue(values []string, current string) string {
+	idx := slices.Index(values, current)
+	return values[(idx + 1) % len(values)]
+}
+
+// nextTestName cycles the word list filter through every installed list.
+func (m *DashboardModel) nextTestName() {
+	m.filter.TestName = nextFilterValue(append([]string{ "" }, m.testNames...), m.filter.TestName)
+}
+
+func (m *DashboardModel) nextMode() {
+	m.filter.Mode = nextFilterValue(dashboardModes, m.filter.Mode)
+}
+
+func filterLabel(value string) string {
+	if value == "" {
+		return "all"
+	}
+
+	return value
+}
+
+func (m *DashboardModel) render(width int) string {
+	builder := &strings.Builder{}
+
+	fmt.Fprintf(builder, "dashboard  words: %s  mode: %s\n\n", filterLabel(m.filter.TestName), filterLabel(m.filter.Mode))
+
+	if m.err != nil {
+		fmt.Fprintf(builder, "error: %v\n", m.err)
+	}
+
+	d := m.dashboard
+
+	if d == nil {
+		builder.WriteString("loading...\n")
+		return builder.String()
+	}
+
+	if d.Tests == 0 {
+		builder.WriteString("no tests taken yet\n")
+		return builder.String()
+	}
+
+	chartWidth := dashboardTrendSize
+
+	if width > 20 {
+		chartWidth = min(chartWidth, width - 20)
+	}
+
+	fmt.Fprintf(builder, "tests: %d  time typed: %s\n\n", d.Tests, d.TimeTyped)
+	fmt.Fprintf(builder, "%-10s %10s %10s\n", "", "last 10", "last 100")
+	fmt.Fprintf(builder, "%-10s %10.1f %10.1f\n", "wpm", d.Last10.Wpm, d.Last100.Wpm)
+	fmt.Fprintf(builder, "%-10s %9.1f%% %9.1f%%\n\n", "accuracy", d.Last10.Accuracy, d.Last100.Accuracy)
+
+	fmt.Fprintf(builder, "%-10s %s %.0f-%.0f\n", "wpm", sparkline(d.WpmTrend, chartWidth), slices.Min(d.WpmTrend), slices.Max(d.WpmTrend))
+	fmt.Fprintf(builder, "%-10s %s %.0f-%.0f%%\n\n", "accuracy", sparkline(d.AccuracyTrend, chartWidth), slices.Min(d.AccuracyTrend), slices.Max(d.AccuracyTrend))
+
+	counts := make([]int, 0, len(d.Days))
+	most := 0
+
+	for _, day := range d.Days {
+		counts = append(counts, day.Tests)
+		most = max(most, day.Tests)
+	}
+
+	fmt.Fprintf(builder, "tests per day since %s (max %d)\n", d.Days[0].Day.Format(time.DateOnly), most)
+	fmt.Fprintf(builder, "%-10s %s\n", "", barline(counts))
+
+	return builder.String()
+}
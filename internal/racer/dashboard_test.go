@@ -0,0 +1,170 @@
+package racer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboard(t *testing.T) {
+	now := time.Now()
+
+	for name, store := range newTestStores(t) {
+		for i := range 12 {
+			test := storeTest(40 + i, 25, false)
+			test.Accuracy = 90
+			test.WpmList = []int{ 1, 2, 3 }
+			test.CreatedAt = now.Add(-time.Duration(12 - i)*time.Hour)
+
+			if i == 0 {
+				test.CreatedAt = now.AddDate(0, 0, -30)
+			}
+
+			if err := store.InsertTest(test); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		timed := storeTest(100, 0, false)
+		timed.Mode = "time"
+		timed.Time = 30
+		timed.Accuracy = 100
+		timed.CreatedAt = now.AddDate(0, 0, -100)
+
+		if err := store.InsertTest(timed); err != nil {
+			t.Fatal(err)
+		}
+
+		d, err := store.GetDashboard(&StatsFilter{})
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if d.Tests != 13 || d.TimeTyped != (12*3 + 30)*time.Second {
+			t.Errorf("%s: got %d tests and %v typed wanted 13 tests and %v", name, d.Tests, d.TimeTyped, 66*time.Second)
+		}
+
+		// the 10 most recent tests are the words tests with 42 to 51 wpm
+		if d.Last10.Tests != 10 || d.Last10.Wpm != 46.5 || d.Last100.Tests != 13 {
+			t.Errorf("%s: averages got %+v and %+v", name, d.Last10, d.Last100)
+		}
+
+		if len(d.WpmTrend) != 13 || d.WpmTrend[0] != 100 || d.WpmTrend[12] != 51 {
+			t.Errorf("%s: wpm trend not oldest first got %v", name, d.WpmTrend)
+		}
+
+		days := 0
+
+		for _, day := range d.Days {
+			days += day.Tests
+		}
+
+		if len(d.Days) != dashboardDays || days != 11 {
+			t.Errorf("%s: got %d tests in the last %d days wanted 11", name, days, len(d.Days))
+		}
+
+		d, err = store.GetDashboard(&StatsFilter{ Mode: "time" })
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if d.Tests != 1 || d.Last10.Wpm != 100 || d.Last10.Accuracy != 100 {
+			t.Errorf("%s: filtered dashboard got %+v", name, d)
+		}
+	}
+}
+
+func TestDashboardLocalDays(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	// shortly after midnight in loc, still the previous day in UTC
+	now := time.Date(2024, 3, 10, 1, 0, 0, 0, loc)
+
+	for name, store := range newTestStores(t) {
+		for _, at := range []time.Duration{ 30*time.Minute, 2*time.Hour, 3*time.Hour } {
+			test := storeTest(50, 25, false)
+			test.CreatedAt = now.Add(-at)
+
+			if err := store.InsertTest(test); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+
+		var d *Dashboard
+		var err error
+
+		switch store := store.(type) {
+		case *MemoryStore:
+			store.now = func() time.Time { return now }
+			d, err = store.GetDashboard(&StatsFilter{})
+		case *DuckDBStore:
+			d, err = GetDashboard(store.DB(), &StatsFilter{}, now)
+		}
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		today, yesterday := d.Days[len(d.Days) - 1], d.Days[len(d.Days) - 2]
+
+		if !today.Day.Equal(time.Date(2024, 3, 10, 0, 0, 0, 0, loc)) || today.Tests != 1 || yesterday.Tests != 2 {
+			t.Errorf("%s: days got %v with %d tests and %d the day before wanted 1 and 2", name, today.Day, today.Tests, yesterday.Tests)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		width int
+		want string
+	}{
+		{ []float64{ 1, 2, 3, 4, 5, 6, 7, 8 }, 0, "▁▂▃▄▅▆▇█" },
+		{ []float64{ 1, 8 }, 1, "█" },
+		{ []float64{ 5, 5 }, 0, "██" },
+		{ nil, 10, "" },
+	}
+
+	for _, test := range tests {
+		if got := sparkline(test.values, test.width); got != test.want {
+			t.Errorf("sparkline(%v, %d) got %q wanted %q", test.values, test.width, got, test.want)
+		}
+	}
+
+	if got, want := barline([]int{ 0, 1, 8 }), " ▁█"; got != want {
+		t.Errorf("barline got %q wanted %q", got, want)
+	}
+}
+
+func TestLineChart(t *testing.T) {
+	series := []chartSeries{
+		{ values: []float64{ 0, 30, 60 }, mark: "o" },
+	}
+
+	got := lineChart(series, []int{ 0, 2, 0 }, 3, 80, "x")
+
+	want := strings.Join([]string{
+		"60 ┤  o",
+		"   ┤ o ",
+		" 0 ┤o  ",
+		"   └───",
+		"     x ",
+		"    1s 3s",
+		"",
+	}, "\n")
+
+	if got != want {
+		t.Errorf("lineChart got\n%s\nwanted\n%s", got, want)
+	}
+
+	// resampled into two columns of the mean of their values
+	if got := resample([]float64{ 1, 3, 5, 7 }, 2, meanOf); !slices.Equal(got, []float64{ 2, 6 }) {
+		t.Errorf("resample got %v wanted [2 6]", got)
+	}
+
+	if got := lineChart(nil, nil, 3, 80, "x"); got != "" {
+		t.Errorf("empty chart got %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package racer
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const dbUsage = "usage: racer db migrate|status"
+
+// RunDb implements the db subcommand. migrate applies the migrations the
+// database is missing and status lists which ones have been applied.
+func RunDb(paths Paths, args []string) error {
+	cmd := flag.NewFlagSet("db", flag.ExitOnError)
+
+	if err := cmd.Parse(args); err != nil {
+		return err
+	}
+
+	if cmd.NArg() != 1 {
+		return errors.New(dbUsage)
+	}
+
+	config, err := ReadOrCreateConfig(paths)
+
+	if err != nil {
+		return err
+	}
+
+	db, err := OpenDB(config.paths.DbFile)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	switch cmd.Arg(0) {
+	case "migrate":
+		applied, err := Migrate(db)
+
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+
+		return nil
+	case "status":
+		statuses, err := GetMigrationStatus(db)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("database: %s\n", config.paths.DbFile)
+		return writeMigrationStatus(os.Stdout, statuses)
+	default:
+		return errors.New(dbUsage)
+	}
+}
+
+func writeMigrationStatus(w io.Writer, statuses []MigrationStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "version\tname\tapplied")
+
+	for _, s := range statuses {
+		applied := "pending"
+
+		if s.Applied {
+			applied = s.AppliedAt.Format(time.DateTime)
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+
+	return tw.Flush()
+}
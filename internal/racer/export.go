@@ -0,0 +1,148 @@
+package racer
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var exportFormats = map[string]string{
+	"csv": "FORMAT csv, HEADER true",
+	"jsonl": "FORMAT json",
+	"parquet": "FORMAT parquet",
+}
+
+// the seed is stored as a signed BIGINT, see the test settings migration.
+// Exports turn it back into the unsigned value that challenge codes use.
+const exportTestsQueryStr = `
+	SELECT
+		id, created_at, test_name, mode, test_duration, test_size,
+		coalesce(quote_id, 0) AS quote_id,
+		coalesce(quote_length, '') AS quote_length,
+		CASE WHEN seed < 0 THEN (seed::HUGEINT + 18446744073709551616)::UBIGINT ELSE coalesce(seed, 0)::UBIGINT END AS seed,
+		accuracy, allow_backspace,
+		coalesce(punctuation, false) AS punctuation,
+		coalesce(numbers, false) AS numbers,
+		coalesce(sampling, 'uniform') AS sampling,
+		coalesce(sampling_size, 0) AS sampling_size,
+		coalesce(lazy, false) AS lazy,
+		coalesce(stop_on_error, 'off') AS stop_on_error,
+		coalesce(adaptive, 'off') AS adaptive,
+		target, input, wpm,
+		coalesce(raw_wpm, 0) AS raw_wpm,
+		cps, rle, raw_input, sample_rate,
+		acc_samples, cps_samples, wpm_samples,
+		(
+			SELECT list({ 'offset_us': offset_us, 'expected': expected, 'typed': typed, 'op': op } ORDER BY seq)
+			FROM keystrokes
+			WHERE keystrokes.test_id = all_tests.id
+		) AS keystrokes
+	FROM all_tests
+	%s
+	ORDER BY id
+	`
+
+// ExportOptions describes an export of the test history. Tests created
+// before Since are left out unless Since is zero.
+type ExportOptions struct {
+	Format string
+	Since time.Time
+	Out string
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// ExportTests writes every test matching opts together with its samples
+// and keystrokes to opts.Out. duckdb writes the file itself so parquet
+// needs no extra dependencies.
+func ExportTests(db *sql.DB, opts ExportOptions) error {
+	format, ok := exportFormats[opts.Format]
+
+	if !ok {
+		return fmt.Errorf("unknown export format %s", opts.Format)
+	}
+
+	if opts.Out == "" {
+		return errors.New("no output file given")
+	}
+
+	var where string
+
+	// COPY does not take parameters, the timestamp is formatted by us so
+	// it is safe to inline.
+	if !opts.Since.IsZero() {
+		where = fmt.Sprintf("WHERE created_at >= TIMESTAMP %s", sqlString(opts.Since.Format(time.DateTime)))
+	}
+
+	query := fmt.Sprintf("COPY (%s) TO %s (%s)", fmt.Sprintf(exportTestsQueryStr, where), sqlString(opts.Out), format)
+
+	_, err := db.Exec(query)
+
+	return err
+}
+
+func parseSince(s string) (time.Time, error) {
+	for _, layout := range []string{ time.DateOnly, time.DateTime, time.RFC3339 } {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %s, use YYYY-MM-DD", s)
+}
+
+// RunExport implements the export subcommand.
+func RunExport(paths Paths, args []string) error {
+	var opts ExportOptions
+	var since string
+
+	cmd := flag.NewFlagSet("export", flag.ExitOnError)
+	cmd.StringVar(&opts.Format, "format", "csv", "output format, one of csv, jsonl or parquet")
+	cmd.StringVar(&since, "since", "", "only export tests taken on or after this date (YYYY-MM-DD)")
+	cmd.StringVar(&opts.Out, "out", "", "file to write the export to")
+
+	if err := cmd.Parse(args); err != nil {
+		return err
+	}
+
+	if opts.Out == "" || cmd.NArg() != 0 {
+		return errors.New("usage: racer export [--format csv|jsonl|parquet] [--since date] --out file")
+	}
+
+	if since != "" {
+		t, err := parseSince(since)
+
+		if err != nil {
+			return err
+		}
+
+		opts.Since = t
+	}
+
+	config, err := ReadOrCreateConfig(paths)
+
+	if err != nil {
+		return err
+	}
+
+	store, err := OpenDuckDBStore(config.paths.DbFile)
+
+	if err != nil {
+		return err
+	}
+
+	defer store.Close()
+
+	if err := ExportTests(store.DB(), opts); err != nil {
+		return err
+	}
+
+	fmt.Printf("exported tests to %s\n", opts.Out)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package racer
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExportTests(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := OpenDuckDBStore(filepath.Join(dir, "racer.db"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Close()
+
+	old := storeTest(40, 25, false)
+	recent := storeTest(60, 25, true)
+	recent.Seed = 1<<63 + 5
+	recent.WpmList = []int{ 50, 60 }
+
+	for _, test := range []*RacerTest{ old, recent } {
+		if err := store.InsertTest(test); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := store.DB().Exec("UPDATE all_tests SET created_at = TIMESTAMP '2020-01-01' WHERE id = ?", old.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	// the readers are told the type of the seed since it does not fit into
+	// the integer types they infer.
+	readers := map[string]string{
+		"csv": "SELECT count(*), max(wpm), max(seed) FROM read_csv(%s, types = { 'seed': 'UBIGINT' })",
+		"jsonl": "SELECT count(*), max((json->>'wpm')::INTEGER), max(json->>'seed') FROM read_ndjson_objects(%s)",
+		"parquet": "SELECT count(*), max(wpm), max(seed) FROM read_parquet(%s)",
+	}
+
+	for format, query := range readers {
+		out := filepath.Join(dir, "tests." + format)
+
+		opts := ExportOptions{
+			Format: format,
+			Since: time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local),
+			Out: out,
+		}
+
+		if err := ExportTests(store.DB(), opts); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		var count, wpm int
+		var seed string
+
+		row := store.DB().QueryRow(fmt.Sprintf(query, sqlString(out)))
+
+		if err := row.Scan(&count, &wpm, &seed); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		if count != 1 || wpm != 60 || seed != fmt.Sprint(recent.Seed) {
+			t.Errorf("%s: export got %d tests wpm %d seed %s wanted 1 test wpm 60 seed %d", format, count, wpm, seed, recent.Seed)
+		}
+	}
+
+	out := filepath.Join(dir, "keystrokes.parquet")
+
+	if err := ExportTests(store.DB(), ExportOptions{ Format: "parquet", Out: out }); err != nil {
+		t.Fatal(err)
+	}
+
+	var keystrokes int
+
+	row := store.DB().QueryRow(fmt.Sprintf("SELECT sum(coalesce(len(keystrokes), 0)) FROM read_parquet(%s)", sqlString(out)))
+
+	if err := row.Scan(&keystrokes); err != nil {
+		t.Fatal(err)
+	}
+
+	if keystrokes != len(recent.Keystrokes) {
+		t.Errorf("exported keystrokes got %d wanted %d", keystrokes, len(recent.Keystrokes))
+	}
+
+	if err := ExportTests(store.DB(), ExportOptions{ Format: "xml", Out: out }); err == nil {
+		t.Errorf("unknown format accepted")
+	}
+}
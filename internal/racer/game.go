@@ -1,7 +1,7 @@
 package racer
 
 import (
-	"github.com/charmbracelet/bubbles/timer"
+	"cmp"
 	"math/rand/v2"
 	"strings"
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,10 +11,17 @@ import (
 	"time"
 	"fmt"
 	"strconv"
-	//"slices"
+	"slices"
+	"unicode"
+	"github.com/arjunmoola/go-racer/internal/engine"
+	"github.com/mattn/go-runewidth"
 )
 
-var rpcg = rand.New(rand.NewPCG(0,1))
+const (
+	linePadding = 4
+	minLineWidth = 20
+	resultsChartHeight = 8
+)
 
 var (
 	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("200"))
@@ -26,162 +33,10 @@ var (
 	viewStyle = lipgloss.NewStyle().Align(lipgloss.Left)
 	overlapSpaceStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#A9A9A9")).Underline(true)
 	timerStyle = lipgloss.NewStyle().PaddingRight(3)
-	lineStyle = lipgloss.NewStyle().PaddingBottom(2)
 	defaultTextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#899499"))
+	newBestStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD700")).Bold(true)
 )
 
-type editOp interface {
-	Byte() byte
-	String() string
-	Code() string
-}
-
-type matchOp byte
-
-func (op matchOp) String() string {
-	return fmt.Sprintf("m(%s)", string(op))
-}
-
-func (op matchOp) Byte() byte {
-	return byte(op)
-}
-
-func (op matchOp) Code() string {
-	return "m"
-}
-
-type mismatchOp byte
-
-func (op mismatchOp) String() string {
-	return fmt.Sprintf("s(%s)", string(op))
-}
-
-func (op mismatchOp) Byte() byte {
-	return byte(op)
-}
-
-func (op mismatchOp) Code() string {
-	return "s"
-}
-
-type deleteOp byte
-
-func (op deleteOp) String() string {
-	return fmt.Sprintf("d(%s)", string(op))
-}
-
-func (op deleteOp) Byte() byte {
-	return byte(op)
-}
-
-func (op deleteOp) Code() string {
-	return "d"
-}
-
-type alignment []editOp
-
-func (a alignment) String() string {
-	builder := &strings.Builder{}
-
-	for _, op := range a {
-		builder.WriteString(op.String())
-	}
-
-	return builder.String()
-}
-
-func (a alignment) compress() string {
-	builder := &strings.Builder{}
-	prevOp := a[0]
-	matchLen := 1
-	buf := ""
-
-	for i := 1; i < len(a); i++ {
-		op := a[i]
-		
-		if op.Code() == prevOp.Code() {
-			matchLen++
-			buf += string(prevOp.Byte())
-		} else {
-			if matchLen == 1 {
-				builder.WriteString(prevOp.String())
-			} else {
-				fmt.Fprintf(builder, "m(%s)", buf)
-				matchLen = 1
-			}
-		}
-		prevOp = op
-	}
-
-	if matchLen == 1 {
-	}
-
-	return builder.String()
-
-}
-
-func (a alignment) rle() string {
-	builder := &strings.Builder{}
-
-	if len(a) == 0 {
-		return ""
-	}
-
-	if len(a) == 1 {
-		return a[0].Code()
-	}
-
-	matchLen := 1
-	prevOp := a[0]
-
-	for i := 1; i < len(a); i++ {
-		op := a[i]
-
-		if op.Code() == prevOp.Code() {
-			matchLen++
-		} else {
-			if matchLen == 1 {
-				fmt.Fprintf(builder, "%s", prevOp.Code())
-			} else {
-				fmt.Fprintf(builder, "%d%s", matchLen, prevOp.Code())
-				matchLen = 1
-			}
-		}
-		prevOp = op
-	}
-
-	if matchLen == 1 {
-		fmt.Fprintf(builder, "%s", prevOp.Code())
-	} else {
-		fmt.Fprintf(builder, "%d%s", matchLen, prevOp.Code())
-	}
-
-	return builder.String()
-}
-
-func (a alignment) final() string {
-	builder := &strings.Builder{}
-	
-	for _, op := range a {
-		if op.Code() == "d" {
-			continue
-		}
-
-		builder.WriteByte(op.Byte())
-	}
-
-	return builder.String()
-}
-
-func (a alignment) rawString() string {
-	builder := &strings.Builder{}
-
-	for _, op := range a {
-		builder.WriteByte(op.Byte())
-	}
-	return builder.String()
-}
-
 type gameStyles struct {
 	match lipgloss.Style
 	mismatch lipgloss.Style
@@ -191,6 +46,7 @@ type gameStyles struct {
 	timer lipgloss.Style
 	overlapSpace lipgloss.Style
 	view lipgloss.Style
+	ghost lipgloss.Style
 }
 
 func gameStylesFromConfig(config *Config2) gameStyles {
@@ -203,6 +59,7 @@ func gameStylesFromConfig(config *Config2) gameStyles {
 		overlapSpace: lipgloss.NewStyle().Foreground(lipgloss.Color(config.OverlapSpaceColor)).Underline(true),
 		view: lipgloss.NewStyle().Align(lipgloss.Left),
 		timer: lipgloss.NewStyle().PaddingRight(3),
+		ghost: lipgloss.NewStyle().Background(lipgloss.Color(config.GhostColor)),
 	}
 }
 
@@ -220,17 +77,19 @@ type Game struct {
 	mode string
 	debug bool
 	started bool
-	target string
-	inputs []byte
-	alignment alignment
-	charIdx int
+	session *engine.Session
 	idx int
-	timer timer.Model
+	clock Clock
+	seed uint64
+	// adaptive is the intensity an adaptive test was weighted with.
+	adaptive string
+	sampling string
+	samplingSize int
+	lazy bool
+	stopOnError string
 	finished bool
 
-	ticks int
-
-	numWordsPerLine int
+	maxLineWidth int
 	lineOffsets []int
 	curLine int
 	windowSize int
@@ -241,34 +100,30 @@ type Game struct {
 	rightIdx int
 	rightLineIdx int
 
-	numMatches int
-	numMisses int
-	numCharsPerSec int
-	charsPerSec []int
-	charBuffer []byte
-
-	wordCount int
-	lastWordIdx int
 	missedWords string
 
-	accuracy float64
-	accs []float64
-
 	allowBackspace bool
-	curWpm int
-	sampleIdx int
-	prevSampleIdx int
-	samples []string
+	punctuation bool
+	numbers bool
+	quote *Quote
+	// quoteDb looks up the quotes of replayed tests when the game is not
+	// part of a racer.
+	quoteDb *QuoteDb
+	ghost *ghost
+	prevBest *PersonalBest
+	bestLoaded bool
+	startTime time.Time
 }
 
 
 func NewGameFromConfig(config *Config2) *Game {
 	game := &Game{
-		numWordsPerLine: config.NumWordsPerLine,
+		maxLineWidth: config.MaxLineWidth,
 		windowSize: config.WindowSize,
 		testSize: config.TestSize,
 		debug: config.Debug,
 		styles: gameStylesFromConfig(config),
+		clock: systemClock{},
 	}
 	return game
 }
@@ -278,19 +133,77 @@ func (g *Game) createTest() {
 	racer := g.racer
 	config := racer.config
 
-	g.testName = config.TestName
-	g.testDuration = config.TestDuration
-	g.testSize = config.TestSize
+	g.ghost = nil
+	g.quote = nil
+	g.adaptive = ""
+
+	if racer.reviewing && len(racer.reviewDue) > 0 {
+		g.createReviewTest(racer.reviewDue)
+		return
+	}
+
+	if racer.challenge == nil && racer.ghost != nil {
+		g.loadGhost(racer.ghost)
+		return
+	}
+
+	c := racer.challenge
+
+	if c == nil {
+		c = challengeFromConfig(config)
+	}
+
+	g.testName = c.TestName
+	g.testDuration = c.Duration
+	g.testSize = c.Size
 	g.wordsTestSize = config.WordsTestSize
-	g.allowBackspace = config.AllowBackspace
-	g.mode = config.GameMode
+	g.allowBackspace = c.AllowBackspace
+	g.stopOnError = c.StopOnError
+	g.punctuation = c.Punctuation
+	g.numbers = c.Numbers
+	g.mode = c.Mode
+	g.seed = c.Seed
+	g.sampling = c.Sampling
+	g.samplingSize = c.SamplingSize
+	g.lazy = c.Lazy
 
-	g.timer = timer.New(time.Duration(g.testDuration)*time.Second)
+	if g.mode == "words" {
+		g.wordsTestSize = c.Size
+	}
 
-	selectedWordList, _ := racer.wordDb.Get(g.testName)
-	words := selectedWordList.Words
+	rng := newRand(g.seed)
+
+	if g.mode == "quote" {
+		var quote *Quote
+		err := ErrNoQuotesFound
+
+		if c.QuoteId > 0 {
+			if l, ok := racer.quoteDb.Get(c.TestName); ok {
+				if q, ok := l.GetById(c.QuoteId); ok {
+					quote, err = q, nil
+				}
+			}
+		} else {
+			quote, err = racer.quoteDb.Random(rng, c.TestName, config.QuoteLength)
+		}
 
-	n := len(words)
+		if err == nil {
+			g.wordsTestSize = 0
+			g.punctuation = false
+			g.numbers = false
+			g.sampling = samplingUniform
+			g.samplingSize = 0
+			g.lazy = false
+			g.quote = quote
+			g.setTarget(quote.Text)
+			return
+		}
+
+		g.testName = config.TestName
+		g.mode = "words"
+	}
+
+	selectedWordList, _ := racer.wordDb.Get(g.testName)
 
 	var testSize int
 
@@ -300,137 +213,378 @@ func (g *Game) createTest() {
 		testSize = g.testSize
 	}
 
-	test := make([]string, 0, testSize)
+	words := selectedWordList.Words
+	var adaptive []float64
+
+	if intensity := adaptiveIntensities[config.Adaptive]; intensity > 0 && racer.challenge == nil && racer.keyStats != nil {
+		adaptive = wordWeights(words, racer.keyStats, intensity)
+		g.adaptive = config.Adaptive
+		// the words depend on the key stats of the moment, the seed alone
+		// can not reproduce them as a challenge.
+		g.seed = 0
+	}
+
+	g.sampling, g.samplingSize = listSampling(selectedWordList, g.sampling, g.samplingSize)
+	sampler := newWordSampler(selectedWordList, g.sampling, g.samplingSize, adaptive)
+	test := generateWords(rng, words, sampler, testSize, g.punctuation, g.numbers)
+	g.lazy = lazyMode(selectedWordList, g.lazy)
 
-	for range testSize {
-		idx := rand.IntN(n)
-		test = append(test, words[idx])
+	if g.lazy {
+		test = stripDiacritics(test)
 	}
 
-	target := strings.Join(test, " ")
+	g.setTarget(test)
+}
 
-	lineOffsets := append(make([]int, 0), 0)
-	count := 0
+// createReviewTest makes a test of the words that are due for review.
+// Review tests can not be shared as challenges since the words depend on
+// the schedule.
+func (g *Game) createReviewTest(due []*ReviewWord) {
+	config := g.racer.config
 
-	for i := 0; i < len(target); i++ {
-		if target[i] == ' ' {
-			count++
-			if count == g.numWordsPerLine {
-				count = 0
-				lineOffsets = append(lineOffsets, i+1)
-			}
-		}
+	g.testName = reviewMode
+	g.mode = reviewMode
+	g.testDuration = config.TestDuration
+	g.allowBackspace = config.AllowBackspace
+	g.stopOnError = config.StopOnError
+	g.punctuation = false
+	g.numbers = false
+	g.seed = 0
+	g.sampling = samplingUniform
+	g.samplingSize = 0
+	g.lazy = false
+
+	words := reviewTestWords(newRand(newSeed(config)), due)
+	g.testSize = len(words)
+	g.wordsTestSize = len(words)
+	g.setTarget(strings.Join(words, " "))
+}
+
+// generateWords draws a test of size words from words with sampler using
+// rng and applies the enabled modifiers.
+func generateWords(rng *rand.Rand, words []string, sampler wordSampler, size int, punctuation bool, numbers bool) string {
+	test := make([]string, 0, size)
+
+	for range size {
+		test = append(test, words[sampler(rng)])
+	}
+
+	if numbers {
+		test = applyNumbers(rng, test)
 	}
 
-	g.lineOffsets = lineOffsets
+	if punctuation {
+		test = applyPunctuation(rng, test)
+	}
+
+	return strings.Join(test, " ")
+}
+
+func (g *Game) setTarget(target string) {
 	g.curLine = 0
 	g.curWindow = 0
-	g.leftIdx = 0
-	g.sampleIdx = 0
-	g.prevSampleIdx = 0
+	g.idx = 0
+
+	g.session = engine.NewSession(target, g.sessionOptions())
+	g.layout()
+}
+
+const defaultStopOnError = "off"
+
+var stopOnErrorOptions = []string{ "off", "letter", "word" }
+
+var stopModes = map[string]engine.StopMode{
+	"off": engine.StopNever,
+	"letter": engine.StopOnLetter,
+	"word": engine.StopOnWord,
+}
+
+func (g *Game) sessionOptions() engine.Options {
+	opts := engine.Options{
+		AllowBackspace: g.allowBackspace,
+		Stop: stopModes[g.stopOnError],
+	}
+
+	if !g.untimed() {
+		opts.Duration = time.Duration(g.testDuration)*time.Second
+	}
+
+	return opts
+}
+
+// target returns the text of the current test.
+func (g *Game) target() []rune {
+	if g.session == nil {
+		return nil
+	}
+
+	return g.session.Target()
+}
+
+// loadGhost sets up a race against test using its target text and test
+// parameters.
+func (g *Game) loadGhost(test *RacerTest) {
+	g.loadTest(test)
+	g.ghost = newGhost(test)
+}
+
+// loadTest prepares the game to replay a previously recorded test.
+func (g *Game) loadTest(test *RacerTest) {
+	g.testName = test.Test
+	g.testDuration = test.Time
+	g.testSize = test.TestSize
+	g.wordsTestSize = test.TestSize
+	// time tests record the words test size of the config, the number of
+	// words drawn for them is the one in the target.
+	if test.Mode == "time" {
+		g.testSize = len(strings.Fields(test.Target))
+	}
+	g.allowBackspace = test.AllowBackspace
+	// replays need the stop mode to apply the deletions stop on word allows
+	// without backspace, the rejected keystrokes are replayed as recorded.
+	g.stopOnError = cmp.Or(test.StopOnError, defaultStopOnError)
+	g.punctuation = test.Punctuation
+	g.numbers = test.Numbers
+	g.mode = test.Mode
+	g.seed = test.Seed
+	g.sampling = cmp.Or(test.Sampling, samplingUniform)
+	g.samplingSize = test.SamplingSize
+	g.lazy = test.Lazy
+	g.adaptive = test.Adaptive
+	g.quote = nil
+
+	quoteDb := g.quoteDb
+
+	if g.racer != nil {
+		quoteDb = g.racer.quoteDb
+	}
+
+	if quoteDb != nil && test.QuoteId > 0 {
+		if l, ok := quoteDb.Get(test.Test); ok {
+			g.quote, _ = l.GetById(test.QuoteId)
+		}
+	}
+
+	g.setTarget(test.Target)
+}
+
+func (g *Game) quoteLength() string {
+	if g.quote == nil {
+		return ""
+	}
+
+	return g.quote.Length
+}
+
+func (g *Game) quoteId() int {
+	if g.quote == nil {
+		return 0
+	}
+
+	return g.quote.Id
+}
+
+// wrapLines flows the words of target into lines no wider than width and
+// returns the offset at which each line begins. Widths are measured in
+// terminal cells so that wide characters take up two columns. A word longer
+// than width is given a line of its own rather than being split.
+func wrapLines(target []rune, width int) []int {
+	lineOffsets := []int{ 0 }
+	lineLen := 0
+	wordStart := 0
+	wordLen := 0
+
+	for i := 0; i <= len(target); i++ {
+		if i < len(target) && target[i] != ' ' {
+			wordLen += runewidth.RuneWidth(target[i])
+			continue
+		}
+
+		if lineLen > 0 && lineLen + wordLen > width {
+			lineOffsets = append(lineOffsets, wordStart)
+			lineLen = 0
+		}
+
+		lineLen += wordLen + 1
+		wordStart = i + 1
+		wordLen = 0
+	}
+
+	return lineOffsets
+}
+
+func (g *Game) lineWidth() int {
+	width := g.width - linePadding
+
+	if g.width <= 0 {
+		width = g.maxLineWidth
+	}
+
+	if g.maxLineWidth > 0 && width > g.maxLineWidth {
+		width = g.maxLineWidth
+	}
+
+	return max(width, minLineWidth)
+}
+
+// setWidth records the terminal width and re-flows the current test so the
+// cursor stays on the character it was on before the resize.
+func (g *Game) setWidth(width int) {
+	g.width = width
+
+	if len(g.target()) != 0 {
+		g.layout()
+	}
+}
+
+func (g *Game) layout() {
+	g.lineOffsets = wrapLines(g.target(), g.lineWidth())
+	g.updateWindow()
+}
+
+func (g *Game) lineOf(idx int) int {
+	line, found := slices.BinarySearch(g.lineOffsets, idx)
+
+	if !found {
+		line--
+	}
+
+	return line
+}
+
+// updateWindow moves the visible window of lines so that it contains the
+// line the cursor is currently on.
+func (g *Game) updateWindow() {
+	g.curLine = g.lineOf(g.idx)
+	g.curWindow = (g.curLine/g.windowSize)*g.windowSize
+	g.leftIdx = g.lineOffsets[g.curWindow]
 
 	if g.curWindow + g.windowSize < len(g.lineOffsets) {
 		g.rightIdx = g.lineOffsets[g.curWindow+g.windowSize]
 	} else {
-		g.rightIdx = len(target)
+		g.rightIdx = len(g.target())
 	}
-
-	g.target = target
 }
 
 func (g *Game) Reset() {
-	g.wordCount = 0
-	g.lastWordIdx = 0
-	g.numMatches = 0
-	g.numMisses = 0
-	g.accuracy = 0
-	g.numCharsPerSec = 0
-	g.charsPerSec = []int{}
-	g.accs = []float64{}
-	g.charBuffer = []byte{}
-	g.alignment = nil
-	g.inputs = nil
-	g.charIdx = 0
+	g.prevBest = nil
+	g.bestLoaded = false
+	g.missedWords = ""
+	g.startTime = time.Time{}
 	g.idx = 0
-	g.timer = timer.New(time.Second*30)
-	g.ticks = 0
 	g.finished = false
 	g.started = false
+
+	if g.session != nil {
+		g.session.Reset()
+		g.updateWindow()
+	}
+}
+
+// elapsed returns the time since the test was started, or the time the
+// test ended at once it has finished.
+func (g *Game) elapsed() time.Duration {
+	if g.session != nil && g.session.Finished() {
+		return g.session.Elapsed()
+	}
+
+	if g.startTime.IsZero() {
+		return 0
+	}
+
+	return g.clock.Now().Sub(g.startTime)
 }
 
-func (g *Game) updateSampleIdx() {
-	g.prevSampleIdx = g.sampleIdx
-	g.sampleIdx = g.idx
+// advance moves the session clock forward and reports whether the test has
+// finished.
+func (g *Game) advance(at time.Duration) bool {
+	g.session.Advance(at)
+	return g.session.Finished()
 }
 
-func (g *Game) sample() {
-	g.ticks++
-	g.accs = append(g.accs, g.accuracy)
-	g.charsPerSec = append(g.charsPerSec, g.numCharsPerSec)
-	g.numCharsPerSec = 0
+func (g *Game) finish() {
+	g.finished = true
+	g.session.Finish(g.elapsed())
 }
 
-func (g *Game) incIndex() {
-	if g.idx+1 < len(g.target) {
-		g.idx++
+func (g *Game) computeMismatchedWords() []wordPair {
+	wordMap := make(map[string]int)
 
-		if g.curLine+1 < len(g.lineOffsets) && g.idx == g.lineOffsets[g.curLine+1] {
-			g.curLine++
-			g.curWindow = (g.curLine/g.windowSize)*g.windowSize
-			if g.curLine % g.windowSize == 0 {
-				g.leftIdx = g.rightIdx
-				if g.curWindow+g.windowSize < len(g.target) {
-					g.rightIdx = g.lineOffsets[g.curWindow+g.windowSize]
-				} else {
-					g.rightIdx = len(g.target)
-				}
-			}
-		}
+	for _, word := range g.session.MismatchedWords() {
+		wordMap[word]++
 	}
+
+	return createWordPairs(wordMap)
 }
 
-func (g *Game) decIndex() {
-	if g.idx-1 > -1 {
-		g.idx--
+func (g *Game) bestParams() *PersonalBestParams {
+	return &PersonalBestParams{
+		testName: g.testName,
+		mode: g.mode,
+		testDuration: g.testDuration,
+		testSize: g.wordsTestSize,
+		quoteLength: g.quoteLength(),
+		allowBackspace: g.allowBackspace,
+		punctuation: g.punctuation,
+		numbers: g.numbers,
+		sampling: g.sampling,
+		samplingSize: g.samplingSize,
+		lazy: g.lazy,
+		stopOnError: g.stopOnError,
+		adaptive: g.adaptive,
+	}
+}
 
-		if g.curLine-1 > -1 && g.idx >= g.lineOffsets[g.curLine-1] && g.idx < g.lineOffsets[g.curLine] {
-			g.curLine--
-			g.curWindow = (g.curLine/g.windowSize)*g.windowSize
+func (g *Game) renderBest(wpm int) string {
+	if !g.bestLoaded {
+		return ""
+	}
 
-			if g.curLine % g.windowSize == 2 {
-				g.rightIdx = g.leftIdx
-				g.leftIdx = g.lineOffsets[g.curWindow]
-			}
+	if g.prevBest == nil {
+		return newBestStyle.Render("new personal best!") + "\n"
+	}
 
-		}
+	delta := wpm - g.prevBest.Wpm
+
+	if delta > 0 {
+		return newBestStyle.Render(fmt.Sprintf("new personal best! (%+d wpm)", delta)) + "\n"
 	}
+
+	return fmt.Sprintf("pb: %d wpm (%+d)\n", g.prevBest.Wpm, delta)
 }
 
-func isValidChar(char byte) bool {
-	return 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || '0' <= char && char <= '9' || char == ' '
+func (g *Game) untimed() bool {
+	return g.mode == "words" || g.mode == "quote" || g.mode == reviewMode
 }
 
-func (g *Game) appendByte(char byte) {
-	g.charIdx = g.idx
-	g.inputs = append(g.inputs, char)
-	g.charBuffer = append(g.charBuffer, char)
-	g.numCharsPerSec++
-	if g.target[g.idx] == char {
-		g.alignment = append(g.alignment, matchOp(char))
-		g.numMatches++
-	} else {
-		g.alignment = append(g.alignment, mismatchOp(char))
-		g.numMisses++
-	}
-	g.accuracy = float64(g.numMatches)/float64(len(g.inputs))
-	g.incIndex()
+func isValidChar(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || char == ' '
+}
 
-	wordIdx := strings.LastIndexByte(g.target[:g.idx], ' ')
-	
-	if wordIdx > 0 && wordIdx != g.lastWordIdx {
-		g.wordCount++
-		g.lastWordIdx = wordIdx
-	}
+// updateCursor moves the cursor to the next character to be typed. Once the
+// whole target has been typed it stays on the last character.
+func (g *Game) updateCursor() {
+	g.idx = max(min(g.session.Pos(), len(g.target())-1), 0)
+	g.updateWindow()
+}
+
+// appendRune feeds a typed character to the session and reports whether
+// the test is finished.
+func (g *Game) appendRune(char rune, at time.Duration) bool {
+	g.session.Type(char, at)
+	g.updateCursor()
+	return g.session.Finished()
+}
+
+// rejectRune records a keystroke the stop mode refused when the test was
+// recorded.
+func (g *Game) rejectRune(char rune, at time.Duration) {
+	g.session.Reject(char, at)
+}
+
+func (g *Game) trimRune(at time.Duration) {
+	g.session.Backspace(at)
+	g.updateCursor()
 }
 
 func (g *Game) restart() {
@@ -439,103 +593,123 @@ func (g *Game) restart() {
 	g.createTest()
 }
 
-func (g *Game) trimByte() byte {
-	b := g.inputs[len(g.inputs)-1]
-	g.inputs = g.inputs[:len(g.inputs)-1]
-	g.alignment = append(g.alignment, deleteOp(b))
-	g.decIndex()
-	return b
-}
-
 type GameTickMsg struct{
 	gameId int
-	Timeout bool
 }
 
-func (g *Game) tickCmd(finished bool, id int) tea.Cmd {
-	return tea.Tick(1*time.Second, func(_ time.Time) tea.Msg {
+func (g *Game) tickCmd(id int) tea.Cmd {
+	return g.clock.Tick(1*time.Second, func(_ time.Time) tea.Msg {
 		return GameTickMsg{
 			gameId: id,
-			Timeout: finished,
 		}
 	})
 }
 
 func (g *Game) startGame(id int) tea.Cmd {
-	if g.mode == "words" {
-		return g.tickCmd(false, id)
-	}
-	return g.timer.Init()
-}
+	g.startTime = g.clock.Now()
 
-func (g *Game) stopGame(id int) tea.Cmd {
-	if g.mode == "words" {
-		return g.tickCmd(true, id)
-	}
-	return g.timer.Stop()
-}
+	var ghostCmd tea.Cmd
 
-func computeCps(nums []int) (total int) {
-	if len(nums) == 0 {
-		return total
+	if g.ghost != nil {
+		g.ghost.reset()
+		ghostCmd = g.ghostTickCmd(id)
 	}
 
-	for _, num := range nums {
-		total += num
-	}
-
-	return total/len(nums)
+	return tea.Batch(g.tickCmd(id), ghostCmd)
 }
 
-func (g *Game) computeMismatchedWords() []wordPair {
-	input := string(g.inputs)
-	n := len(input)
-	target := g.target[:n]
+// remaining is the time left in a timed test.
+func (g *Game) remaining() time.Duration {
+	return time.Duration(g.testDuration)*time.Second - g.session.Elapsed().Truncate(time.Second)
+}
 
-	wordMap := make(map[string]int)
-	leftIdx := 0
+// renderResultsChart plots the wpm and raw wpm of every second of the test
+// with the seconds mistakes were made in below and the accuracy under it.
+func (g *Game) renderResultsChart() string {
+	samples := g.session.Samples()
 
-	for i := 0; i < len(target); i++ {
-		if target[i] != ' ' {
-			continue
-		}
+	if len(samples) == 0 {
+		return ""
+	}
 
-		if word := string(target[leftIdx:i]); word != string(input[leftIdx:i]) {
-			wordMap[word]++
-		}
+	wpm := make([]float64, 0, len(samples))
+	raw := make([]float64, 0, len(samples))
+	acc := make([]float64, 0, len(samples))
 
-		i++
-		leftIdx = i
+	for _, sample := range samples {
+		wpm = append(wpm, float64(sample.Wpm))
+		raw = append(raw, float64(sample.RawWpm()))
+		acc = append(acc, sample.Accuracy*100)
 	}
 
-	if word := string(target[leftIdx:]); word != string(input[leftIdx:]) {
-		for i := n; i < len(g.target) && g.target[i] != ' '; i++ {
-			i++
-			word += string(g.target[i])
-		}
-		wordMap[word]++
+	series := []chartSeries{
+		{ values: raw, mark: g.styles.defaultStyle.Render("·") },
+		{ values: wpm, mark: g.styles.match.Render("•") },
 	}
 
-	return createWordPairs(wordMap)
+	width := g.lineWidth()
+	errors := g.session.Keystrokes().ErrorsPerSecond(len(samples))
+
+	builder := &strings.Builder{}
+	builder.WriteString(lineChart(series, errors, resultsChartHeight, width, g.styles.mismatch.Render("x")))
+	fmt.Fprintf(builder, "%s wpm %s raw %s errors\n", g.styles.match.Render("•"), g.styles.defaultStyle.Render("·"), g.styles.mismatch.Render("x"))
+	fmt.Fprintf(builder, "accuracy %s\n", sparkline(resample(acc, width, meanOf), width))
+
+	return builder.String()
 }
 
 func (g *Game) View() string {
 	builder := &strings.Builder{}
+	s := g.session
 
 	if g.finished {
 		builder.WriteString("Results: \n\n")
 		fmt.Fprintf(builder, "name: %s\n", g.testName)
 		fmt.Fprintf(builder, "mode: %s\n", g.mode)
-		fmt.Fprintf(builder, "time: %d s\n", g.ticks)
-		fmt.Fprintf(builder, "accuracry: %.2f%%\n", g.accuracy*100)
-		fmt.Fprintf(builder, "cps: %d\n", computeCps(g.charsPerSec))
-		//fmt.Fprintf(builder, "%v\n", g.charsPerSec)
-		//fmt.Fprintf(builder, "%s\n", g.alignment)
-		fmt.Fprintf(builder, "rle: %s\n", g.alignment.rle())
+		if g.punctuation || g.numbers {
+			fmt.Fprintf(builder, "punctuation: %v numbers: %v\n", g.punctuation, g.numbers)
+		}
+		if g.seed != 0 || g.quote != nil {
+			fmt.Fprintf(builder, "challenge: %s\n", g.challenge().Code())
+		}
+		if g.adaptive != "" && g.adaptive != defaultAdaptive {
+			fmt.Fprintf(builder, "adaptive: %s\n", g.adaptive)
+		}
+		if g.sampling != samplingUniform && g.quote == nil {
+			fmt.Fprintf(builder, "sampling: %s\n", g.sampling)
+		}
+		if g.lazy && g.quote == nil {
+			builder.WriteString("lazy mode: yes\n")
+		}
+		if g.stopOnError != defaultStopOnError {
+			fmt.Fprintf(builder, "stop on error: %s, %d rejected\n", g.stopOnError, s.Rejected())
+		}
+		fmt.Fprintf(builder, "time: %d s\n", len(s.Samples()))
+		fmt.Fprintf(builder, "wpm: %d\n", s.Wpm())
+		fmt.Fprintf(builder, "raw: %d\n", s.RawWpm())
+		builder.WriteString(g.renderBest(s.Wpm()))
+		fmt.Fprintf(builder, "accuracry: %.2f%%\n", s.Accuracy()*100)
+		fmt.Fprintf(builder, "cps: %d\n", s.Cps())
+		fmt.Fprintf(builder, "consistency: %.1f%% cv\n", engine.Consistency(s.Samples())*100)
+		if at, ok := s.Keystrokes().FirstError(); ok {
+			fmt.Fprintf(builder, "first error: %.1f s\n", at.Seconds())
+		} else {
+			builder.WriteString("first error: none\n")
+		}
+		//fmt.Fprintf(builder, "%s\n", s.Alignment())
+		fmt.Fprintf(builder, "rle: %s\n", s.Alignment().Rle())
+		if g.quote != nil {
+			fmt.Fprintf(builder, "quote: %s\n", g.quote.Source)
+		}
+		if g.ghost != nil {
+			fmt.Fprintf(builder, "ghost: test %d, wpm %d\n", g.ghost.test.Id, g.ghost.test.Wpm)
+		}
 		if g.missedWords != "" {
 			fmt.Fprintf(builder, "missed words: %s\n", g.missedWords)
 		}
 		builder.WriteRune('\n')
+		builder.WriteString(g.renderResultsChart())
+		builder.WriteRune('\n')
 		fmt.Fprintf(builder, "press esc to go to main menu\n")
 		fmt.Fprintf(builder, "press r to restart\n")
 		fmt.Fprintf(builder, "press enter to go to next test\n")
@@ -559,28 +733,30 @@ func (g *Game) View() string {
 		var timeView string
 		switch g.mode {
 		case "time":
-			timeView = timerStyle.Render(g.timer.View())
-			wordCountView = timerStyle.Render(g.styles.defaultStyle.Render(fmt.Sprintf("count: %d", g.wordCount)))
-		case "words":
-			timeView = timerStyle.Render(fmt.Sprintf("time: %d", g.ticks))
-			wordCountView = timerStyle.Render(g.styles.defaultStyle.Render(fmt.Sprintf("count: %d/%d", g.wordCount, g.wordsTestSize)))
+			timeView = timerStyle.Render(g.remaining().String())
+			wordCountView = timerStyle.Render(g.styles.defaultStyle.Render(fmt.Sprintf("count: %d", s.WordCount())))
+		case "words", reviewMode:
+			timeView = timerStyle.Render(fmt.Sprintf("time: %d", len(s.Samples())))
+			wordCountView = timerStyle.Render(g.styles.defaultStyle.Render(fmt.Sprintf("count: %d/%d", s.WordCount(), g.wordsTestSize)))
+		case "quote":
+			timeView = timerStyle.Render(fmt.Sprintf("time: %d", len(s.Samples())))
+			wordCountView = timerStyle.Render(g.styles.defaultStyle.Render(fmt.Sprintf("count: %d/%d", s.WordCount(), strings.Count(string(s.Target()), " ")+1)))
 		}
 
-		var acc float64
+		acc := s.Accuracy()*100
+		accView := timerStyle.Render(g.styles.defaultStyle.Render(fmt.Sprintf("acc: %.2f%%", acc)))
+		wpmView := timerStyle.Render(g.styles.defaultStyle.Render(fmt.Sprintf("wpm: %d", s.Wpm())))
 
-		if len(g.accs) == 0 {
-			acc = g.accuracy
-		} else {
-			acc = g.accs[len(g.accs)-1]
+		var ghostView string
+
+		if g.ghost != nil {
+			ghostView = timerStyle.Render(g.styles.ghost.Render(fmt.Sprintf("ghost: %+d", g.idx-g.ghost.idx)))
 		}
-		acc *= 100
-		accView := timerStyle.Render(g.styles.defaultStyle.Render(fmt.Sprintf("acc: %.2f%%", acc)))
 
-		builder.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, timeView, accView, wordCountView))
+		builder.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, timeView, wpmView, accView, wordCountView, ghostView))
 		builder.WriteRune('\n')
-		s := viewStyle.Render(g.render2())
 		builder.WriteRune('\n')
-		builder.WriteString(s)
+		builder.WriteString(viewStyle.Render(g.render2()))
 		builder.WriteRune('\n')
 
 		if g.debug {
@@ -650,74 +826,64 @@ func renderLineOffsets(lineOffsets []int, curIndex int, windowIdx int, windowSiz
 }
 
 func (g *Game) render2() string {
+	target := g.target()
+	inputs := g.session.Input()
 	lineOffsets := g.lineOffsets
-	leftIdx := g.leftIdx
-	rightIdx := g.rightIdx
-	windowIdx := g.curWindow
-
-	lineIdx := windowIdx+1
-	end := g.idx
-
-	var s string
-
-	var lines []string
 	builder := &strings.Builder{}
+	end := min(g.curWindow+g.windowSize, len(lineOffsets))
+	ghostIdx := -1
 
-	for i := leftIdx; i < end; i++ {
-		if g.target[i] == g.inputs[i] {
-			if g.lineOffsets[lineIdx] == i+1 && g.target[i] == ' ' {
-				lines = append(lines, s)
-				s = ""
-				if lineIdx+1 < len(lineOffsets) {
-					lineIdx++
-				}
-			} else{
-				//s += charMatchStyle.Render(string(g.inputs[i]))
-				s += g.styles.match.Render(string(g.inputs[i]))
-			}
-			//builder.WriteString(charMatchStyle.Render(string(g.inputs[i])))
-		} else if g.inputs[i] == ' ' && g.target[i] != ' ' {
-			//s += overlapSpaceStyle.Render(string(g.target[i]))
-			s += g.styles.overlapSpace.Render(string(g.target[i]))
-		} else {
-			//s += charMismatchStyle.Render(string(g.inputs[i]))
-			s += g.styles.mismatch.Render(string(g.inputs[i]))
-			//builder.WriteString(charMismatchStyle.Render(string(g.inputs[i])))
-		}
+	if g.ghost != nil {
+		ghostIdx = g.ghost.idx
 	}
 
-	//s += highlightStyle.Render(string(g.target[end]))
-	s += g.styles.cursor.Render(string(g.target[end]))
+	for line := g.curWindow; line < end; line++ {
+		start := lineOffsets[line]
+		stop := len(target)
 
-	if end == lineOffsets[lineIdx]-1 {
-		lines = append(lines, s)
-		s = ""
-		if lineIdx+1 < len(lineOffsets) {
-			lineIdx++
+		if line+1 < len(lineOffsets) {
+			stop = lineOffsets[line+1]
 		}
-	}
 
-	//builder.WriteString(highlightStyle.Render(string(g.target[end])))
+		var s string
+
+		for i := start; i < stop; i++ {
+			// the space that ends a wrapped line is only drawn when it
+			// carries information: the cursor, the ghost or a mistake.
+			lineBreak := i == stop-1 && line+1 < len(lineOffsets) && target[i] == ' '
+
+			var char string
+			var style lipgloss.Style
+
+			switch {
+			case i < g.idx:
+				if target[i] == inputs[i] {
+					if lineBreak && i != ghostIdx {
+						continue
+					}
+					char, style = string(inputs[i]), g.styles.match
+				} else if inputs[i] == ' ' && target[i] != ' ' {
+					char, style = string(target[i]), g.styles.overlapSpace
+				} else {
+					char, style = string(inputs[i]), g.styles.mismatch
+				}
+			case i == g.idx:
+				char, style = string(target[i]), g.styles.cursor
+			default:
+				if lineBreak && i != ghostIdx {
+					continue
+				}
+				char, style = string(target[i]), g.styles.defaultStyle
+			}
 
-	for i := end+1; i < rightIdx; i++ {
-		if i+1 == lineOffsets[lineIdx] {
-			lines = append(lines, s)
-			s = ""
-			if lineIdx+1 < len(lineOffsets) {
-				lineIdx++
+			if i == ghostIdx && i != g.idx {
+				style = g.styles.ghost.Inherit(style)
 			}
-		} else {
-			//s += string(defaultTextStyle.Render(string(g.target[i])))
-			s += g.styles.defaultStyle.Render(string(g.target[i]))
-		}
-	}
 
-	if s != "" {
-		lines = append(lines, s)
-	}
+			s += style.Render(char)
+		}
 
-	for _, line := range lines {
-		fmt.Fprintln(builder, lineStyle.Render(line))
+		fmt.Fprintln(builder, g.styles.line.Render(s))
 	}
 
 	return builder.String()
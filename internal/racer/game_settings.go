@@ -1,12 +1,10 @@
 package racer
 
 import (
-	"path/filepath"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"strings"
 	"strconv"
-	"os"
 	"fmt"
 	"time"
 )
@@ -174,11 +172,27 @@ func (s *GameSettings) updateConfig() {
 			config.TestDuration = int(t)
 		case "allow backspace":
 			config.AllowBackspace = value == "yes"
+		case "punctuation":
+			config.Punctuation = value == "yes"
+		case "numbers":
+			config.Numbers = value == "yes"
 		case "mode":
 			config.GameMode = value
 		case "words test size":
 			t, _ := strconv.ParseInt(value, 10, 64)
 			config.WordsTestSize = int(t)
+		case "quote length":
+			config.QuoteLength = value
+		case "ghost":
+			config.Ghost = value
+		case "adaptive":
+			config.Adaptive = value
+		case "sampling":
+			config.Sampling = value
+		case "lazy mode":
+			config.LazyMode = value == "yes"
+		case "stop on error":
+			config.StopOnError = value
 		}
 	}
 }
@@ -189,19 +203,9 @@ type clearGameSettingsMsg struct{}
 
 func (s *GameSettings) SaveSettings() tea.Msg {
 	config := s.model.config
-	path := filepath.Join(defaultConfigDir, "config.toml")
-
 	s.updateConfig()
 
-	file, err := os.Create(path)
-
-	if err != nil {
-		return gameSettingsErr(err)
-	}
-
-	defer file.Close()
-
-	if err := config.write(file); err != nil {
+	if err := config.Save(); err != nil {
 		return gameSettingsErr(err)
 	}
 
@@ -240,15 +244,55 @@ func (s *GameSettings) FromConfig(config *Config2) {
 		allowBack = "no"
 	}
 	s.SetSelectedOption("allow backspace", allowBack)
+	s.SetSelectedOption("punctuation", yesNo(config.Punctuation))
+	s.SetSelectedOption("numbers", yesNo(config.Numbers))
 	s.SetSelectedOption("mode", config.GameMode)
 	s.SetSelectedOption("words test size", strconv.Itoa(config.WordsTestSize))
+	s.SetSelectedOption("quote length", config.QuoteLength)
+	s.SetSelectedOption("ghost", config.Ghost)
+	s.SetSelectedOption("adaptive", config.Adaptive)
+	s.SetSelectedOption("sampling", config.Sampling)
+	s.SetSelectedOption("lazy mode", yesNo(config.LazyMode))
+	s.SetSelectedOption("stop on error", config.StopOnError)
+
+	s.showModeOptions(config.GameMode)
+}
 
-	switch config.GameMode {
-	case "words":
-		s.HideSettingsOption("time")
+// showModeOptions hides the settings that do not apply to the given game
+// mode and unhides the ones that do.
+func (s *GameSettings) showModeOptions(mode string) {
+	s.HideSettingsOption("time")
+	s.HideSettingsOption("words test size")
+	s.HideSettingsOption("quote length")
+	s.HideSettingsOption("punctuation")
+	s.HideSettingsOption("numbers")
+	s.HideSettingsOption("adaptive")
+	s.HideSettingsOption("sampling")
+	s.HideSettingsOption("lazy mode")
+
+	switch mode {
 	case "time":
-		s.HideSettingsOption("words test size")
+		s.UnhideSettingsOption("time")
+	case "words":
+		s.UnhideSettingsOption("words test size")
+	case "quote":
+		s.UnhideSettingsOption("quote length")
+	}
+
+	if mode != "quote" {
+		s.UnhideSettingsOption("punctuation")
+		s.UnhideSettingsOption("numbers")
+		s.UnhideSettingsOption("adaptive")
+		s.UnhideSettingsOption("sampling")
+		s.UnhideSettingsOption("lazy mode")
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
 	}
+	return "no"
 }
 
 func (s *GameSettings) resetSaveState() {
@@ -350,7 +394,7 @@ func (s *GameSettings) render() string {
 	//}
 
 	if s.saveSuccess {
-		builder.WriteString("settings saved to .go-racer/config.json\n")
+		fmt.Fprintf(builder, "settings saved to %s\n", s.model.config.paths.ConfigFile)
 	}
 
 	if s.err != nil {
@@ -0,0 +1,250 @@
+package racer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeClock only moves forward when one of its ticks fires.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		c.now = c.now.Add(d)
+		return fn(c.now)
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	target := []rune("the quick brown fox jumps over the lazy dog")
+
+	tests := []struct{
+		width int
+		want []int
+	}{
+		{ 100, []int{ 0 } },
+		{ 20, []int{ 0, 20, 40 } },
+		{ 10, []int{ 0, 10, 20, 31, 40 } },
+		{ 3, []int{ 0, 4, 10, 16, 20, 26, 31, 35, 40 } },
+	}
+
+	for _, test := range tests {
+		got := wrapLines(target, test.width)
+
+		if !slices.Equal(got, test.want) {
+			t.Errorf("wrapLines width %d got %v wanted %v", test.width, got, test.want)
+		}
+	}
+}
+
+func TestWrapLinesWideRunes(t *testing.T) {
+	// every ideograph takes up two cells of the terminal
+	target := []rune("日本語 日本語 日本語")
+
+	got := wrapLines(target, 8)
+	want := []int{ 0, 4, 8 }
+
+	if !slices.Equal(got, want) {
+		t.Errorf("wrapLines got %v wanted %v", got, want)
+	}
+}
+
+func TestGameResizeKeepsCursor(t *testing.T) {
+	g := &Game{
+		windowSize: 2,
+		width: 100,
+		allowBackspace: true,
+	}
+	g.setTarget("the quick brown fox jumps over the lazy dog")
+
+	for _, char := range "the quick brown fox jumps " {
+		g.appendRune(char, 0)
+	}
+
+	g.setWidth(minLineWidth+linePadding)
+
+	if g.idx != 26 {
+		t.Errorf("cursor moved on resize got %d wanted %d", g.idx, 26)
+	}
+
+	if g.curLine != 1 {
+		t.Errorf("incorrect current line got %d wanted %d", g.curLine, 1)
+	}
+
+	if g.leftIdx > g.idx || g.idx >= g.rightIdx {
+		t.Errorf("cursor %d outside of window [%d, %d)", g.idx, g.leftIdx, g.rightIdx)
+	}
+
+	for range 7 {
+		g.trimRune(0)
+	}
+
+	if g.curLine != 0 || g.curWindow != 0 {
+		t.Errorf("incorrect line after backspace got line %d window %d", g.curLine, g.curWindow)
+	}
+}
+
+func TestGhostAdvance(t *testing.T) {
+	test := &RacerTest{
+		Target: "ab cd",
+		Keystrokes: []engine.Keystroke{
+			{ Offset: 100*time.Millisecond, Op: "m" },
+			{ Offset: 200*time.Millisecond, Op: "s" },
+			{ Offset: 300*time.Millisecond, Op: "d" },
+			{ Offset: 400*time.Millisecond, Op: "m" },
+		},
+	}
+
+	gh := newGhost(test)
+
+	gh.advance(250*time.Millisecond)
+
+	if gh.idx != 2 {
+		t.Errorf("incorrect ghost position got %d wanted %d", gh.idx, 2)
+	}
+
+	gh.advance(time.Second)
+
+	if gh.idx != 2 || gh.keyIdx != 4 {
+		t.Errorf("incorrect ghost state got idx %d keyIdx %d", gh.idx, gh.keyIdx)
+	}
+}
+
+func TestGhostTestSize(t *testing.T) {
+	config := DefaultConfig2()
+	config.TestSize = 200
+	config.WordsTestSize = 25
+
+	tests := []struct{
+		test *RacerTest
+		size int
+	}{
+		{ &RacerTest{ Test: "english", Mode: "words", TestSize: 3, Target: "ab cd ef", Seed: 7 }, 3 },
+		{ &RacerTest{ Test: "english", Mode: "time", Time: 15, TestSize: 50, Target: "ab cd", Seed: 7 }, 2 },
+	}
+
+	for _, tt := range tests {
+		g := NewGameFromConfig(config)
+		g.loadGhost(tt.test)
+
+		if got := g.bestParams().testSize; got != tt.test.TestSize {
+			t.Errorf("%s: incorrect personal best test size got %d wanted %d", tt.test.Mode, got, tt.test.TestSize)
+		}
+
+		if got := g.challenge().Size; got != tt.size {
+			t.Errorf("%s: incorrect challenge size got %d wanted %d", tt.test.Mode, got, tt.size)
+		}
+	}
+}
+
+func TestRenderBest(t *testing.T) {
+	g := &Game{}
+
+	if got := g.renderBest(60); got != "" {
+		t.Errorf("rendered personal best before it was loaded got %q", got)
+	}
+
+	g.bestLoaded = true
+	g.prevBest = &PersonalBest{ Wpm: 70 }
+
+	if got, want := g.renderBest(60), "pb: 70 wpm (-10)\n"; got != want {
+		t.Errorf("incorrect personal best got %q wanted %q", got, want)
+	}
+
+	g.prevBest.Wpm = 50
+
+	if got := g.renderBest(60); !strings.Contains(got, "new personal best! (+10 wpm)") {
+		t.Errorf("new personal best not shown got %q", got)
+	}
+}
+
+func TestGameCursorStaysOnLastChar(t *testing.T) {
+	g := &Game{
+		windowSize: 3,
+	}
+	g.setTarget("ab")
+
+	if g.appendRune('a', 0) {
+		t.Fatalf("game finished before the target was typed")
+	}
+
+	if !g.appendRune('b', 0) {
+		t.Fatalf("game not finished after the target was typed")
+	}
+
+	if g.idx != 1 {
+		t.Errorf("incorrect cursor got %d wanted %d", g.idx, 1)
+	}
+}
+
+func TestGenerateWordsSeed(t *testing.T) {
+	words := []string{ "alpha", "bravo", "charlie", "delta", "echo", "foxtrot" }
+
+	tests := []struct{
+		punctuation bool
+		numbers bool
+		want string
+	}{
+		{ false, false, "delta charlie delta delta foxtrot bravo" },
+		{ true, true, "Delta charlie delta, (delta), 69 \"bravo\"." },
+	}
+
+	for _, test := range tests {
+		got := generateWords(newRand(42), words, uniformSampler(len(words)), 6, test.punctuation, test.numbers)
+
+		if got != test.want {
+			t.Errorf("incorrect words for seed 42 got %q wanted %q", got, test.want)
+		}
+	}
+
+	if generateWords(newRand(1), words, uniformSampler(len(words)), 20, true, true) == generateWords(newRand(2), words, uniformSampler(len(words)), 20, true, true) {
+		t.Errorf("different seeds produced the same words")
+	}
+}
+
+func TestGameClock(t *testing.T) {
+	clock := &fakeClock{ now: time.Unix(0, 0) }
+	g := NewGameFromConfig(DefaultConfig2())
+	g.clock = clock
+	g.mode = "time"
+	g.testDuration = 2
+	g.setTarget("a b c d e f")
+	g.started = true
+	g.startGame(g.id)
+
+	g.appendRune('a', g.elapsed())
+
+	for i := range 2 {
+		msg := g.tickCmd(g.id)()
+
+		if tick, ok := msg.(GameTickMsg); !ok || tick.gameId != g.id {
+			t.Fatalf("unexpected tick message %v", msg)
+		}
+
+		if finished := g.advance(g.elapsed()); finished != (i == 1) {
+			t.Errorf("tick %d finished %v", i+1, finished)
+		}
+	}
+
+	if n := len(g.session.Samples()); n != 2 {
+		t.Errorf("incorrect number of samples got %d wanted %d", n, 2)
+	}
+
+	if got := g.session.Samples()[0].Wpm; got != 12 {
+		t.Errorf("incorrect wpm after the first second got %d wanted %d", got, 12)
+	}
+
+	if g.remaining() != 0 {
+		t.Errorf("time left after the test ended got %v", g.remaining())
+	}
+}
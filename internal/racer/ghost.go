@@ -0,0 +1,143 @@
+package racer
+
+import (
+	"time"
+	"unicode/utf8"
+	"github.com/arjunmoola/go-racer/internal/engine"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const ghostTickRate = 50*time.Millisecond
+
+// ghost replays the cursor movement of a previous test so that it can be
+// raced against. Only the position is tracked, what the ghost typed is not
+// shown.
+type ghost struct {
+	test *RacerTest
+	keyIdx int
+	idx int
+}
+
+func newGhost(test *RacerTest) *ghost {
+	return &ghost{
+		test: test,
+	}
+}
+
+func (gh *ghost) reset() {
+	gh.keyIdx = 0
+	gh.idx = 0
+}
+
+// advance applies every keystroke of the ghost typed before elapsed.
+func (gh *ghost) advance(elapsed time.Duration) {
+	keystrokes := gh.test.Keystrokes
+
+	for gh.keyIdx < len(keystrokes) && keystrokes[gh.keyIdx].Offset <= elapsed {
+		switch keystrokes[gh.keyIdx].Op {
+		case engine.OpDelete:
+			gh.idx = max(gh.idx-1, 0)
+		case engine.OpReject:
+			// a rejected key leaves the cursor where it was
+		default:
+			gh.idx = min(gh.idx+1, utf8.RuneCountInString(gh.test.Target))
+		}
+		gh.keyIdx++
+	}
+}
+
+type ghostTickMsg struct {
+	gameId int
+}
+
+func (g *Game) ghostTickCmd(id int) tea.Cmd {
+	return g.clock.Tick(ghostTickRate, func(_ time.Time) tea.Msg {
+		return ghostTickMsg{
+			gameId: id,
+		}
+	})
+}
+
+// configBestParams returns the parameters of the tests the game will create
+// with the current config. The sampling strategy and lazy mode are the ones
+// the word list allows, like they are when the test is created.
+func configBestParams(config *Config2, wordDb *WordDb) *PersonalBestParams {
+	params := &PersonalBestParams{
+		testName: config.TestName,
+		mode: config.GameMode,
+		testDuration: config.TestDuration,
+		testSize: config.WordsTestSize,
+		allowBackspace: config.AllowBackspace,
+		punctuation: config.Punctuation,
+		numbers: config.Numbers,
+		sampling: config.Sampling,
+		samplingSize: samplingSize(config, config.Sampling),
+		stopOnError: config.StopOnError,
+		adaptive: config.Adaptive,
+	}
+
+	if list, ok := wordDb.Get(config.TestName); ok {
+		params.sampling, params.samplingSize = listSampling(list, params.sampling, params.samplingSize)
+		params.lazy = lazyMode(list, config.LazyMode)
+	}
+
+	if config.GameMode == "quote" {
+		params.testName = config.QuoteList
+		params.testSize = 0
+		params.quoteLength = config.QuoteLength
+		params.punctuation = false
+		params.numbers = false
+		params.sampling = samplingUniform
+		params.samplingSize = 0
+		params.lazy = false
+		params.adaptive = defaultAdaptive
+	}
+
+	return params
+}
+
+type ghostLoadedMsg struct {
+	test *RacerTest
+}
+
+type loadGhostErr error
+
+// loadGhostCmd fetches the test the next game will race against. A test
+// picked from the stats table takes precedence over the configured ghost
+// test id, which in turn takes precedence over the personal best.
+func (r *RacerModel) loadGhostCmd() tea.Cmd {
+	config := r.config
+	id := r.ghostTestId
+
+	if id == 0 {
+		id = config.GhostTestId
+	}
+
+	return func() tea.Msg {
+		if id > 0 {
+			test, err := r.store.GetTest(id)
+
+			if err != nil {
+				return loadGhostErr(err)
+			}
+
+			return ghostLoadedMsg{ test }
+		}
+
+		if config.Ghost != "pb" {
+			return ghostLoadedMsg{}
+		}
+
+		test, found, err := r.store.GetBestTest(configBestParams(config, r.wordDb))
+
+		if err != nil {
+			return loadGhostErr(err)
+		}
+
+		if !found {
+			return ghostLoadedMsg{}
+		}
+
+		return ghostLoadedMsg{ test }
+	}
+}
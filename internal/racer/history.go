@@ -0,0 +1,555 @@
+package racer
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const testPageSize = 20
+
+// testColumn is a column of the test history table. Tests are sorted by
+// expr in sql and by compare in memory, which have to agree.
+type testColumn struct {
+	name string
+	title string
+	width int
+	expr string
+	value func(test *RacerTest) string
+	compare func(a, b *RacerTest) int
+}
+
+func compareTests[T cmp.Ordered](field func(test *RacerTest) T) func(a, b *RacerTest) int {
+	return func(a, b *RacerTest) int {
+		return cmp.Compare(field(a), field(b))
+	}
+}
+
+func compareTestFlags(field func(test *RacerTest) bool) func(a, b *RacerTest) int {
+	return func(a, b *RacerTest) int {
+		return compareBool(field(a), field(b))
+	}
+}
+
+var testColumns = []testColumn{
+	{
+		name: "id", title: "Id", width: 6, expr: "id",
+		value: func(t *RacerTest) string { return fmt.Sprintf("%d", t.Id) },
+		compare: compareTests(func(t *RacerTest) int { return t.Id }),
+	},
+	{
+		name: "date", title: "Date", width: 16, expr: "created_at",
+		value: func(t *RacerTest) string { return t.CreatedAt.Local().Format("2006-01-02 15:04") },
+		compare: func(a, b *RacerTest) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	},
+	{
+		name: "name", title: "Name", width: 10, expr: "test_name",
+		value: func(t *RacerTest) string { return t.Test },
+		compare: compareTests(func(t *RacerTest) string { return t.Test }),
+	},
+	{
+		name: "duration", title: "Test Duration", width: 10, expr: "test_duration",
+		value: func(t *RacerTest) string { return fmt.Sprintf("%d", t.Time) },
+		compare: compareTests(func(t *RacerTest) int { return t.Time }),
+	},
+	{
+		name: "mode", title: "Mode", width: 10, expr: "mode",
+		value: func(t *RacerTest) string { return t.Mode },
+		compare: compareTests(func(t *RacerTest) string { return t.Mode }),
+	},
+	{
+		name: "backspace", title: "Allow Backspace", width: 10, expr: "allow_backspace",
+		value: func(t *RacerTest) string { return fmt.Sprintf("%v", t.AllowBackspace) },
+		compare: compareTestFlags(func(t *RacerTest) bool { return t.AllowBackspace }),
+	},
+	{
+		name: "punctuation", title: "Punctuation", width: 10, expr: "coalesce(punctuation, false)",
+		value: func(t *RacerTest) string { return fmt.Sprintf("%v", t.Punctuation) },
+		compare: compareTestFlags(func(t *RacerTest) bool { return t.Punctuation }),
+	},
+	{
+		name: "numbers", title: "Numbers", width: 10, expr: "coalesce(numbers, false)",
+		value: func(t *RacerTest) string { return fmt.Sprintf("%v", t.Numbers) },
+		compare: compareTestFlags(func(t *RacerTest) bool { return t.Numbers }),
+	},
+	{
+		name: "sampling", title: "Sampling", width: 14, expr: "coalesce(sampling, 'uniform')",
+		value: func(t *RacerTest) string { return samplingLabel(t.Sampling, t.SamplingSize, t.Lazy) },
+		compare: compareTests(func(t *RacerTest) string { return cmp.Or(t.Sampling, samplingUniform) }),
+	},
+	{
+		name: "stop", title: "Stop On Error", width: 8, expr: "coalesce(stop_on_error, 'off')",
+		value: func(t *RacerTest) string { return cmp.Or(t.StopOnError, defaultStopOnError) },
+		compare: compareTests(func(t *RacerTest) string { return cmp.Or(t.StopOnError, defaultStopOnError) }),
+	},
+	{
+		name: "size", title: "Test Size", width: 10, expr: "test_size",
+		value: func(t *RacerTest) string { return fmt.Sprintf("%d", t.TestSize) },
+		compare: compareTests(func(t *RacerTest) int { return t.TestSize }),
+	},
+	{
+		name: "accuracy", title: "Accuracy", width: 10, expr: "accuracy",
+		value: func(t *RacerTest) string { return fmt.Sprintf("%.2f", t.Accuracy) },
+		compare: compareTests(func(t *RacerTest) float64 { return t.Accuracy }),
+	},
+	{
+		name: "words", title: "Words", width: 10, expr: "target",
+		value: func(t *RacerTest) string { return t.Target },
+		compare: compareTests(func(t *RacerTest) string { return t.Target }),
+	},
+	{
+		name: "input", title: "Input", width: 10, expr: "input",
+		value: func(t *RacerTest) string { return t.Input },
+		compare: compareTests(func(t *RacerTest) string { return t.Input }),
+	},
+	{
+		name: "wpm", title: "Wpm", width: 10, expr: "wpm",
+		value: func(t *RacerTest) string { return fmt.Sprintf("%d", t.Wpm) },
+		compare: compareTests(func(t *RacerTest) int { return t.Wpm }),
+	},
+	{
+		name: "cps", title: "Cps", width: 10, expr: "cps",
+		value: func(t *RacerTest) string { return fmt.Sprintf("%d", t.Cps) },
+		compare: compareTests(func(t *RacerTest) int { return t.Cps }),
+	},
+	{
+		name: "rle", title: "Rle", width: 10, expr: "coalesce(rle, '')",
+		value: func(t *RacerTest) string { return t.Rle },
+		compare: compareTests(func(t *RacerTest) string { return t.Rle }),
+	},
+	{
+		name: "raw_input", title: "Raw Input", width: 10, expr: "coalesce(raw_input, '')",
+		value: func(t *RacerTest) string { return t.RawInput },
+		compare: compareTests(func(t *RacerTest) string { return t.RawInput }),
+	},
+}
+
+func testColumnIndex(name string) int {
+	return slices.IndexFunc(testColumns, func(c testColumn) bool { return c.name == name })
+}
+
+// TestQuery selects a page of the tests matching Filter sorted by the
+// column named SortBy, ties are broken by id in the same direction. A zero
+// Limit selects every test.
+type TestQuery struct {
+	Filter StatsFilter
+	SortBy string
+	Desc bool
+	Offset int
+	Limit int
+}
+
+// TestPage is a page of tests along with the number of tests matching the
+// query it was selected with.
+type TestPage struct {
+	Tests []*RacerTest
+	Offset int
+	Total int
+}
+
+// recentTestsQuery selects the 100 most recent tests.
+func recentTestsQuery() *TestQuery {
+	return &TestQuery{ SortBy: "id", Desc: true, Limit: 100 }
+}
+
+// column returns the sort column, tests are sorted by id when SortBy is
+// not a column.
+func (q *TestQuery) column() *testColumn {
+	idx := max(testColumnIndex(q.SortBy), 0)
+	return &testColumns[idx]
+}
+
+func (q *TestQuery) direction() string {
+	if q.Desc {
+		return "DESC"
+	}
+
+	return "ASC"
+}
+
+func (s *MemoryStore) GetTests(query *TestQuery) (*TestPage, error) {
+	s.mu.Lock()
+
+	var tests []*RacerTest
+
+	for _, test := range s.tests {
+		if query.Filter.matches(test) {
+			tests = append(tests, copyTest(test, false))
+		}
+	}
+
+	s.mu.Unlock()
+
+	column := query.column()
+
+	slices.SortFunc(tests, func(a, b *RacerTest) int {
+		c := cmp.Or(column.compare(a, b), cmp.Compare(a.Id, b.Id))
+
+		if query.Desc {
+			return -c
+		}
+
+		return c
+	})
+
+	page := &TestPage{ Offset: query.Offset, Total: len(tests) }
+	start := min(max(query.Offset, 0), len(tests))
+	end := len(tests)
+
+	if query.Limit > 0 {
+		end = min(start + query.Limit, end)
+	}
+
+	page.Tests = tests[start:end]
+
+	return page, nil
+}
+
+func (s *DuckDBStore) GetTests(query *TestQuery) (*TestPage, error) {
+	return QueryTests(s.db, query)
+}
+
+func (t *RacerTest) row() []string {
+	row := make([]string, 0, len(testColumns))
+
+	for _, column := range testColumns {
+		row = append(row, column.value(t))
+	}
+
+	return row
+}
+
+func convertTestsToRows(tests []*RacerTest) []table.Row {
+	rows := make([]table.Row, 0, len(tests))
+
+	for _, test := range tests {
+		rows = append(rows, test.row())
+	}
+	return rows
+}
+
+// historyDateRanges are the number of days the history can be limited to,
+// zero shows every test.
+var historyDateRanges = []int{ 0, 1, 7, 30, 365 }
+
+// TestHistoryModel is the page of the statistics screen listing every test
+// taken one page at a time. Opening a test shows its details instead of
+// the table.
+type TestHistoryModel struct {
+	table table.Model
+	query TestQuery
+	total int
+	dateRange int
+	testNames []string
+	detail *RacerTest
+	styles gameStyles
+}
+
+func NewTestHistoryModel(config *Config2, testNames []string) *TestHistoryModel {
+	m := &TestHistoryModel{
+		table: table.New(),
+		query: TestQuery{ SortBy: "id", Desc: true, Limit: testPageSize },
+		testNames: testNames,
+		styles: gameStylesFromConfig(config),
+	}
+
+	m.setColumns()
+
+	return m
+}
+
+// setColumns marks the sort column and its direction in the header.
+func (m *TestHistoryModel) setColumns() {
+	columns := make([]table.Column, 0, len(testColumns))
+
+	for _, c := range testColumns {
+		title := c.title
+
+		if c.name == m.query.column().name {
+			if m.query.Desc {
+				title += " ↓"
+			} else {
+				title += " ↑"
+			}
+		}
+
+		columns = append(columns, table.Column{ Title: title, Width: c.width })
+	}
+
+	m.table.SetColumns(columns)
+}
+
+func (m *TestHistoryModel) setPage(page *TestPage) {
+	m.total = page.Total
+	m.query.Offset = page.Offset
+	m.table.SetRows(convertTestsToRows(page.Tests))
+	m.table.SetCursor(0)
+}
+
+// selectedId returns the id of the test under the cursor.
+func (m *TestHistoryModel) selectedId() (int, bool) {
+	row := m.table.SelectedRow()
+
+	if row == nil {
+		return 0, false
+	}
+
+	var id int
+
+	if _, err := fmt.Sscan(row[0], &id); err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
+
+// nextPage moves the query to the next page and reports whether there is
+// one.
+func (m *TestHistoryModel) nextPage() bool {
+	if m.query.Offset + m.query.Limit >= m.total {
+		return false
+	}
+
+	m.query.Offset += m.query.Limit
+	return true
+}
+
+func (m *TestHistoryModel) prevPage() bool {
+	if m.query.Offset == 0 {
+		return false
+	}
+
+	m.query.Offset = max(m.query.Offset - m.query.Limit, 0)
+	return true
+}
+
+// filtered is called after the filter changed, the first page of the new
+// results is shown.
+func (m *TestHistoryModel) filtered() {
+	m.query.Offset = 0
+}
+
+func (m *TestHistoryModel) nextTestName() {
+	m.query.Filter.TestName = nextFilterValue(append([]string{ "" }, m.testNames...), m.query.Filter.TestName)
+	m.filtered()
+}
+
+func (m *TestHistoryModel) nextMode() {
+	m.query.Filter.Mode = nextFilterValue(dashboardModes, m.query.Filter.Mode)
+	m.filtered()
+}
+
+// nextBackspace cycles the backspace filter through all, on and off.
+func (m *TestHistoryModel) nextBackspace() {
+	filter := &m.query.Filter
+
+	switch {
+	case filter.AllowBackspace == nil:
+		on := true
+		filter.AllowBackspace = &on
+	case *filter.AllowBackspace:
+		off := false
+		filter.AllowBackspace = &off
+	default:
+		filter.AllowBackspace = nil
+	}
+
+	m.filtered()
+}
+
+// nextDateRange limits the tests to the next of historyDateRanges, counted
+// in whole days ending today.
+func (m *TestHistoryModel) nextDateRange(now time.Time) {
+	m.dateRange = (m.dateRange + 1) % len(historyDateRanges)
+	days := historyDateRanges[m.dateRange]
+	m.query.Filter.Since = time.Time{}
+
+	if days > 0 {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		m.query.Filter.Since = today.AddDate(0, 0, 1 - days)
+	}
+
+	m.filtered()
+}
+
+func (m *TestHistoryModel) nextSortColumn() {
+	idx := (testColumnIndex(m.query.SortBy) + 1) % len(testColumns)
+	m.query.SortBy = testColumns[idx].name
+	m.query.Offset = 0
+	m.setColumns()
+}
+
+func (m *TestHistoryModel) reverseSort() {
+	m.query.Desc = !m.query.Desc
+	m.query.Offset = 0
+	m.setColumns()
+}
+
+func (m *TestHistoryModel) filterLabels() string {
+	dateRange := "all"
+
+	if days := historyDateRanges[m.dateRange]; days == 1 {
+		dateRange = "today"
+	} else if days > 1 {
+		dateRange = fmt.Sprintf("last %d days", days)
+	}
+
+	backspace := "all"
+
+	if b := m.query.Filter.AllowBackspace; b != nil {
+		backspace = yesNo(*b)
+	}
+
+	return fmt.Sprintf(
+		"words: %s  mode: %s  backspace: %s  date: %s",
+		filterLabel(m.query.Filter.TestName),
+		filterLabel(m.query.Filter.Mode),
+		backspace,
+		dateRange,
+	)
+}
+
+func (m *TestHistoryModel) render(width int) string {
+	if m.detail != nil {
+		return m.renderDetail(width)
+	}
+
+	builder := &strings.Builder{}
+
+	first := min(m.query.Offset + 1, m.total)
+	last := min(m.query.Offset + m.query.Limit, m.total)
+
+	fmt.Fprintf(builder, "all tests %d-%d of %d  %s\n", first, last, m.total, m.filterLabels())
+	builder.WriteString(m.table.View())
+
+	return builder.String()
+}
+
+func (m *TestHistoryModel) renderDetail(width int) string {
+	t := m.detail
+	builder := &strings.Builder{}
+
+	fmt.Fprintf(builder, "test %d taken %s\n\n", t.Id, t.CreatedAt.Local().Format(time.DateTime))
+	fmt.Fprintf(builder, "name: %s\n", t.Test)
+
+	switch t.Mode {
+	case "time":
+		fmt.Fprintf(builder, "mode: time %d s\n", t.Time)
+	case "words":
+		fmt.Fprintf(builder, "mode: words %d\n", t.TestSize)
+	default:
+		fmt.Fprintf(builder, "mode: %s\n", t.Mode)
+	}
+
+	fmt.Fprintf(builder, "backspace: %s punctuation: %s numbers: %s\n", yesNo(t.AllowBackspace), yesNo(t.Punctuation), yesNo(t.Numbers))
+	fmt.Fprintf(builder, "sampling: %s stop on error: %s adaptive: %s\n", samplingLabel(t.Sampling, t.SamplingSize, t.Lazy), cmp.Or(t.StopOnError, defaultStopOnError), cmp.Or(t.Adaptive, defaultAdaptive))
+	fmt.Fprintf(builder, "wpm: %d raw: %d cps: %d accuracy: %.2f%%\n\n", t.Wpm, t.RawWpm, t.Cps, t.Accuracy)
+	builder.WriteString(renderTestDiff(t, &m.styles, width))
+
+	return builder.String()
+}
+
+// renderTestDiff colours every character of the target by whether the
+// character left in its place matched, followed by the input and, when
+// the edits of the test were recorded, every key typed with the deleted
+// ones struck through. Keys rejected by a stop mode are shown as mistakes
+// that never made it into the input.
+func renderTestDiff(test *RacerTest, styles *gameStyles, width int) string {
+	alignment, err := engine.ParseAlignment(test.Rle, test.RawInput)
+	var result engine.Alignment
+
+	if err == nil && len(alignment) > 0 {
+		result = alignment.Result()
+	} else {
+		alignment = nil
+		target := []rune(test.Target)
+
+		for i, char := range []rune(test.Input) {
+			if i < len(target) && target[i] == char {
+				result = append(result, engine.MatchOp(char))
+			} else {
+				result = append(result, engine.MismatchOp(char))
+			}
+		}
+	}
+
+	// mistyped spaces are drawn as underscores so they can be seen
+	render := func(char rune, matched bool) string {
+		if matched {
+			return styles.match.Render(string(char))
+		}
+
+		if char == ' ' {
+			char = '_'
+		}
+
+		return styles.mismatch.Render(string(char))
+	}
+
+	target := &strings.Builder{}
+
+	for i, char := range []rune(test.Target) {
+		if i < len(result) {
+			target.WriteString(render(char, result[i].Code() == engine.OpMatch))
+		} else {
+			target.WriteString(styles.defaultStyle.Render(string(char)))
+		}
+	}
+
+	input := &strings.Builder{}
+
+	for _, op := range result {
+		input.WriteString(render(op.Rune(), op.Code() == engine.OpMatch))
+	}
+
+	wrap := lipgloss.NewStyle()
+
+	if width > linePadding {
+		wrap = wrap.Width(width - linePadding)
+	}
+
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "target:\n%s\n\n", wrap.Render(target.String()))
+	fmt.Fprintf(builder, "input:\n%s\n", wrap.Render(input.String()))
+
+	if alignment == nil {
+		return builder.String()
+	}
+
+	deleted := styles.defaultStyle.Strikethrough(true)
+	keys := &strings.Builder{}
+
+	// a deletion removes the last key that is still in the input
+	var kept []int
+
+	rendered := make([]string, len(alignment))
+
+	for i, op := range alignment {
+		switch op.Code() {
+		case engine.OpDelete:
+			if len(kept) > 0 {
+				j := kept[len(kept) - 1]
+				kept = kept[:len(kept) - 1]
+				rendered[j] = deleted.Render(string(alignment[j].Rune()))
+			}
+		case engine.OpReject:
+			rendered[i] = render(op.Rune(), false)
+		default:
+			kept = append(kept, i)
+			rendered[i] = render(op.Rune(), op.Code() == engine.OpMatch)
+		}
+	}
+
+	for _, s := range rendered {
+		keys.WriteString(s)
+	}
+
+	fmt.Fprintf(builder, "\nkeystrokes:\n%s\n", wrap.Render(keys.String()))
+
+	return builder.String()
+}
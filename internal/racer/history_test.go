@@ -0,0 +1,140 @@
+package racer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetTests(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		for i, wpm := range []int{ 50, 70, 60, 40, 80 } {
+			test := storeTest(wpm, 25, false)
+			test.AllowBackspace = i%2 == 0
+			test.CreatedAt = time.Date(2024, 1, 1 + i, 12, 0, 0, 0, time.UTC)
+
+			if err := store.InsertTest(test); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+
+		wpms := func(page *TestPage) []int {
+			var wpms []int
+
+			for _, test := range page.Tests {
+				wpms = append(wpms, test.Wpm)
+			}
+
+			return wpms
+		}
+
+		on := true
+
+		tests := []struct {
+			query TestQuery
+			total int
+			want []int
+		}{
+			{ TestQuery{ SortBy: "wpm", Limit: 2 }, 5, []int{ 40, 50 } },
+			{ TestQuery{ SortBy: "wpm", Limit: 2, Offset: 4 }, 5, []int{ 80 } },
+			{ TestQuery{ SortBy: "wpm", Desc: true }, 5, []int{ 80, 70, 60, 50, 40 } },
+			{ TestQuery{ SortBy: "id", Desc: true, Limit: 3 }, 5, []int{ 80, 40, 60 } },
+			{ TestQuery{ SortBy: "wpm", Filter: StatsFilter{ AllowBackspace: &on } }, 3, []int{ 50, 60, 80 } },
+			{
+				TestQuery{
+					SortBy: "date",
+					Filter: StatsFilter{
+						Since: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+						Until: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+					},
+				},
+				2,
+				[]int{ 70, 60 },
+			},
+			{ TestQuery{ SortBy: "wpm", Filter: StatsFilter{ Mode: "time" } }, 0, nil },
+		}
+
+		for _, test := range tests {
+			page, err := store.GetTests(&test.query)
+
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+
+			if got := wpms(page); page.Total != test.total || !slices.Equal(got, test.want) {
+				t.Errorf("%s: GetTests(%+v) got %v of %d wanted %v of %d", name, test.query, got, page.Total, test.want, test.total)
+			}
+		}
+	}
+}
+
+func TestHistoryPaging(t *testing.T) {
+	m := NewTestHistoryModel(DefaultConfig2(), nil)
+	m.setPage(&TestPage{ Total: 45 })
+
+	for _, want := range []int{ 20, 40 } {
+		if !m.nextPage() || m.query.Offset != want {
+			t.Errorf("next page got offset %d wanted %d", m.query.Offset, want)
+		}
+	}
+
+	if m.nextPage() {
+		t.Errorf("moved past the last page")
+	}
+
+	m.nextSortColumn()
+
+	if m.query.SortBy != "date" || m.query.Offset != 0 {
+		t.Errorf("sort column got %s at offset %d wanted date at 0", m.query.SortBy, m.query.Offset)
+	}
+
+	if m.prevPage() {
+		t.Errorf("moved before the first page")
+	}
+
+	for _, want := range []string{ "yes", "no", "all" } {
+		m.nextBackspace()
+
+		if got := m.filterLabels(); !strings.Contains(got, "backspace: " + want) {
+			t.Errorf("backspace filter got %q wanted %s", got, want)
+		}
+	}
+
+	m.nextDateRange(time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC))
+
+	if got, want := m.query.Filter.Since, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("date filter got %v wanted %v", got, want)
+	}
+}
+
+func TestRenderTestDiff(t *testing.T) {
+	config := DefaultConfig2()
+	styles := gameStylesFromConfig(config)
+
+	test := &RacerTest{
+		Target: "ab cd",
+		Input: "ab xd",
+		Rle: "3m2sdm",
+		RawInput: "ab xyyd",
+	}
+
+	got := renderTestDiff(test, &styles, 0)
+
+	for _, want := range []string{ "target:\nab cd", "input:\nab xd", "keystrokes:\nab xyd" } {
+		if !strings.Contains(got, want) {
+			t.Errorf("diff got %q does not contain %q", got, want)
+		}
+	}
+
+	// imported tests have no edits and are compared character by character
+	test.Rle = ""
+	test.RawInput = ""
+	test.Input = "ab  d"
+
+	got = renderTestDiff(test, &styles, 0)
+
+	if !strings.Contains(got, "input:\nab _d") || strings.Contains(got, "keystrokes") {
+		t.Errorf("diff without edits got %q", got)
+	}
+}
@@ -0,0 +1,282 @@
+package racer
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const monkeytypeSource = "monkeytype"
+
+// the columns a monkeytype export needs for a row to become a test. _id is
+// optional, rows without one are identified by their timestamp.
+var monkeytypeColumns = []string{ "wpm", "acc", "mode", "mode2", "timestamp" }
+
+// SkippedRow is a row of an imported file that did not become a test.
+type SkippedRow struct {
+	Line int
+	Reason string
+}
+
+// ImportReport summarizes an import. Duplicates counts the rows that were
+// imported before and is not part of Skipped.
+type ImportReport struct {
+	Imported int
+	Duplicates int
+	Skipped []SkippedRow
+}
+
+func (r *ImportReport) skip(line int, format string, args ...any) {
+	r.Skipped = append(r.Skipped, SkippedRow{ Line: line, Reason: fmt.Sprintf(format, args...) })
+}
+
+func (r *ImportReport) write(w io.Writer) {
+	fmt.Fprintf(w, "imported %d tests, %d already imported, %d skipped\n", r.Imported, r.Duplicates, len(r.Skipped))
+
+	for _, row := range r.Skipped {
+		fmt.Fprintf(w, "  line %d: %s\n", row.Line, row.Reason)
+	}
+}
+
+type monkeytypeRow struct {
+	columns map[string]int
+	record []string
+}
+
+func (r *monkeytypeRow) get(name string) string {
+	i, ok := r.columns[name]
+
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+
+	return strings.TrimSpace(r.record[i])
+}
+
+func (r *monkeytypeRow) float(name string) (float64, error) {
+	n, err := strconv.ParseFloat(r.get(name), 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, r.get(name))
+	}
+
+	return n, nil
+}
+
+// test maps a row onto a racer test. Monkeytype always allows backspace
+// and names its word lists like racer does, so the language is used as
+// the test name. Tests that are not timed keep the seconds they took.
+func (r *monkeytypeRow) test() (*RacerTest, error) {
+	wpm, err := r.float("wpm")
+
+	if err != nil {
+		return nil, err
+	}
+
+	acc, err := r.float("acc")
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw := wpm
+
+	if r.get("rawWpm") != "" {
+		if raw, err = r.float("rawWpm"); err != nil {
+			return nil, err
+		}
+	}
+
+	duration := 0.0
+
+	if r.get("testDuration") != "" {
+		if duration, err = r.float("testDuration"); err != nil {
+			return nil, err
+		}
+	}
+
+	timestamp, err := strconv.ParseInt(r.get("timestamp"), 10, 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q", r.get("timestamp"))
+	}
+
+	language := r.get("language")
+
+	if language == "" {
+		language = defaultTestName
+	}
+
+	test := &RacerTest{
+		Test: language,
+		Mode: r.get("mode"),
+		Accuracy: acc,
+		AllowBackspace: true,
+		Punctuation: r.get("punctuation") == "true",
+		Numbers: r.get("numbers") == "true",
+		Lazy: r.get("lazyMode") == "true",
+		Time: int(math.Round(duration)),
+		Wpm: int(math.Round(wpm)),
+		RawWpm: int(math.Round(raw)),
+		Cps: int(math.Round(raw*5/60)),
+		SampleRate: 1,
+		Source: monkeytypeSource,
+		ExternalId: r.get("_id"),
+		CreatedAt: time.UnixMilli(timestamp),
+	}
+
+	if test.ExternalId == "" {
+		test.ExternalId = r.get("timestamp")
+	}
+
+	switch test.Mode {
+	case "time", "words":
+		size, err := strconv.Atoi(r.get("mode2"))
+
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid %s test size %q", test.Mode, r.get("mode2"))
+		}
+
+		if test.Mode == "time" {
+			test.Time = size
+		} else {
+			test.TestSize = size
+		}
+	case "quote":
+		// monkeytype quote ids do not match the ids of the quotes
+		// shipped with racer.
+	default:
+		return nil, fmt.Errorf("unsupported mode %q", test.Mode)
+	}
+
+	return test, nil
+}
+
+// ImportMonkeytype reads a csv export of the monkeytype test history into
+// store. Rows that were imported before are counted as duplicates and rows
+// that can not be mapped onto a test are reported as skipped.
+func ImportMonkeytype(store Store, r io.Reader) (*ImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty monkeytype export")
+		}
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		columns[strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")] = i
+	}
+
+	for _, name := range monkeytypeColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("not a monkeytype export: missing column %s", name)
+		}
+	}
+
+	report := &ImportReport{}
+
+	for {
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+
+			if errors.As(err, &parseErr) {
+				report.skip(parseErr.StartLine, "%v", parseErr.Err)
+				continue
+			}
+
+			return report, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &monkeytypeRow{ columns: columns, record: record }
+
+		test, err := row.test()
+
+		if err != nil {
+			report.skip(line, "%v", err)
+			continue
+		}
+
+		found, err := store.HasImportedTest(test.Source, test.ExternalId)
+
+		if err != nil {
+			return report, err
+		}
+
+		if found {
+			report.Duplicates++
+			continue
+		}
+
+		if err := store.InsertTest(test); err != nil {
+			return report, err
+		}
+
+		report.Imported++
+	}
+
+	return report, nil
+}
+
+// RunImport implements the import subcommand.
+func RunImport(paths Paths, args []string) error {
+	cmd := flag.NewFlagSet("import", flag.ExitOnError)
+
+	if err := cmd.Parse(args); err != nil {
+		return err
+	}
+
+	if cmd.NArg() != 2 || cmd.Arg(0) != monkeytypeSource {
+		return errors.New("usage: racer import monkeytype <file.csv>")
+	}
+
+	file, err := os.Open(cmd.Arg(1))
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	config, err := ReadOrCreateConfig(paths)
+
+	if err != nil {
+		return err
+	}
+
+	store, err := OpenDuckDBStore(config.paths.DbFile)
+
+	if err != nil {
+		return err
+	}
+
+	defer store.Close()
+
+	report, err := ImportMonkeytype(store, file)
+
+	if report != nil {
+		report.write(os.Stdout)
+	}
+
+	return err
+}
@@ -0,0 +1,83 @@
+package racer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func importTestFile(t *testing.T, store Store) *ImportReport {
+	file, err := os.Open(filepath.Join("testdata", "monkeytype.csv"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	report, err := ImportMonkeytype(store, file)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return report
+}
+
+func TestImportMonkeytype(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		report := importTestFile(t, store)
+
+		if report.Imported != 3 || report.Duplicates != 0 || len(report.Skipped) != 2 {
+			t.Errorf("%s: first import got %+v wanted 3 imported and 2 skipped", name, report)
+		}
+
+		if len(report.Skipped) == 2 && (report.Skipped[0].Line != 5 || report.Skipped[1].Line != 6) {
+			t.Errorf("%s: skipped lines got %+v wanted lines 5 and 6", name, report.Skipped)
+		}
+
+		report = importTestFile(t, store)
+
+		if report.Imported != 0 || report.Duplicates != 3 {
+			t.Errorf("%s: second import got %+v wanted 3 duplicates", name, report)
+		}
+
+		tests, err := store.GetAllTests()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tests) != 3 {
+			t.Fatalf("%s: got %d tests wanted 3", name, len(tests))
+		}
+
+		words := tests[1]
+
+		if words.Mode != "words" || words.TestSize != 25 || words.Test != "english_1k" || !words.Punctuation || !words.Numbers || words.Wpm != 72 || words.RawWpm != 76 {
+			t.Errorf("%s: words test mapped to %+v", name, words)
+		}
+
+		if words.Time != 21 || !words.Lazy {
+			t.Errorf("%s: words test duration and lazy mode got %d %v wanted %d %v", name, words.Time, words.Lazy, 21, true)
+		}
+
+		if want := time.UnixMilli(1688334600000); !words.CreatedAt.Equal(want) {
+			t.Errorf("%s: created at got %v wanted %v", name, words.CreatedAt, want)
+		}
+
+		best, found, err := store.GetPersonalBest(tests[2].bestParams())
+
+		if err != nil || !found || best.Wpm != 88 || best.Size != 30 {
+			t.Errorf("%s: imported time test not part of personal bests got %+v", name, best)
+		}
+	}
+}
+
+func TestImportNotMonkeytype(t *testing.T) {
+	if _, err := ImportMonkeytype(NewMemoryStore(), strings.NewReader("id,speed\n1,2\n")); err == nil {
+		t.Errorf("csv without monkeytype columns accepted")
+	}
+}
@@ -0,0 +1,378 @@
+package racer
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"github.com/arjunmoola/go-racer/internal/engine"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultKeyboardLayout = "qwerty"
+	worstBigramsSize = 10
+	// bigrams typed fewer times are left out of the worst bigrams, a single
+	// mistake would put them at the top.
+	minBigramAttempts = 3
+)
+
+// KeyboardLayout lists the rows of a keyboard from the top, each row holds
+// the characters its keys type without shift.
+type KeyboardLayout []string
+
+var keyboardLayouts = map[string]KeyboardLayout{
+	"qwerty": { "`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./" },
+	"dvorak": { "`1234567890[]", "',.pyfgcrl/=\\", "aoeuidhtns-", ";qjkxbmwvz" },
+	"colemak": { "`1234567890-=", "qwfpgjluy;[]\\", "arstdhneio'", "zxcvbkm,./" },
+}
+
+// the symbols typed with shift and the key they are on, which is the same
+// for the layouts racer knows.
+var shiftedSymbols = map[rune]rune{
+	'~': '`', '!': '1', '@': '2', '#': '3', '$': '4', '%': '5', '^': '6',
+	'&': '7', '*': '8', '(': '9', ')': '0', '_': '-', '+': '=', '{': '[',
+	'}': ']', '|': '\\', ':': ';', '"': '\'', '<': ',', '>': '.', '?': '/',
+}
+
+// keyOf returns the key char is typed with.
+func keyOf(char rune) rune {
+	if key, ok := shiftedSymbols[char]; ok {
+		return key
+	}
+
+	return unicode.ToLower(char)
+}
+
+// KeyboardLayout returns the layout named by KeyboardLayout, layouts
+// defined in the config take precedence over the builtin ones.
+func (c *Config2) KeyboardLayout() (KeyboardLayout, error) {
+	name := c.KeyboardLayoutName
+
+	if name == "" {
+		name = defaultKeyboardLayout
+	}
+
+	if rows, ok := c.Layouts[name]; ok {
+		return KeyboardLayout(rows), nil
+	}
+
+	if layout, ok := keyboardLayouts[name]; ok {
+		return layout, nil
+	}
+
+	return keyboardLayouts[defaultKeyboardLayout], fmt.Errorf("unknown keyboard layout %s", name)
+}
+
+const keyStatsQueryStr = `
+	WITH keys AS (
+		SELECT
+			expected,
+			op,
+			lag(expected) OVER w AS prev,
+			lag(op) OVER w AS prev_op,
+			offset_us - lag(offset_us) OVER w AS latency_us
+		FROM keystrokes
+		WHERE test_id IN (SELECT id FROM all_tests WHERE %s)
+		WINDOW w AS (PARTITION BY test_id ORDER BY seq)
+	)
+	SELECT
+		%s,
+		count(*),
+		count(*) FILTER (WHERE op IN ('s', 'r')),
+		coalesce(sum(latency_us) FILTER (WHERE prev_op NOT IN ('d', 'r')), 0),
+		count(*) FILTER (WHERE prev_op NOT IN ('d', 'r'))
+	FROM keys
+	WHERE op != 'd' %s
+	GROUP BY ALL
+	`
+
+// GetKeyStats aggregates the keystrokes of the tests matching filter the
+// same way engine.KeyStats does.
+func GetKeyStats(db *sql.DB, filter *StatsFilter) (*engine.KeyStats, error) {
+	where, args := filter.where()
+	stats := engine.NewKeyStats()
+
+	queries := []struct {
+		key string
+		cond string
+		add func(key string, stat *engine.KeyStat)
+	}{
+		{
+			key: "expected",
+			add: func(key string, stat *engine.KeyStat) {
+				char, _ := utf8.DecodeRuneInString(key)
+				stats.Chars[char] = stat
+			},
+		},
+		{
+			key: "prev || expected",
+			cond: "AND prev_op NOT IN ('d', 'r')",
+			add: func(key string, stat *engine.KeyStat) {
+				stats.Bigrams[key] = stat
+			},
+		},
+	}
+
+	for _, q := range queries {
+		rows, err := db.Query(fmt.Sprintf(keyStatsQueryStr, where, q.key, q.cond), args...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var key string
+			var latencyUs int64
+			stat := &engine.KeyStat{}
+
+			if err := rows.Scan(&key, &stat.Attempts, &stat.Errors, &latencyUs, &stat.Latencies); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			stat.Latency = time.Duration(latencyUs)*time.Microsecond
+			q.add(key, stat)
+		}
+
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		rows.Close()
+	}
+
+	return stats, nil
+}
+
+func (s *MemoryStore) GetKeyStats(filter *StatsFilter) (*engine.KeyStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := engine.NewKeyStats()
+
+	for _, test := range s.tests {
+		if filter.matches(test) {
+			stats.Add(test.Keystrokes)
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *DuckDBStore) GetKeyStats(filter *StatsFilter) (*engine.KeyStats, error) {
+	return GetKeyStats(s.db, filter)
+}
+
+// BigramStat is the stat of a bigram.
+type BigramStat struct {
+	Bigram string
+	engine.KeyStat
+}
+
+// worstBigrams returns the n bigrams with the highest error rate that were
+// typed at least minBigramAttempts times. Slower bigrams come first when
+// the error rates are equal.
+func worstBigrams(stats *engine.KeyStats, n int) []BigramStat {
+	var bigrams []BigramStat
+
+	for bigram, stat := range stats.Bigrams {
+		if stat.Attempts >= minBigramAttempts {
+			bigrams = append(bigrams, BigramStat{ bigram, *stat })
+		}
+	}
+
+	slices.SortFunc(bigrams, func(a, b BigramStat) int {
+		return cmp.Or(
+			cmp.Compare(b.ErrorRate(), a.ErrorRate()),
+			cmp.Compare(b.AvgLatency(), a.AvgLatency()),
+			strings.Compare(a.Bigram, b.Bigram),
+		)
+	})
+
+	return bigrams[:min(n, len(bigrams))]
+}
+
+// keyStats merges the stats of the characters typed with each key.
+func keyStats(stats *engine.KeyStats) map[rune]*engine.KeyStat {
+	keys := make(map[rune]*engine.KeyStat)
+
+	for char, stat := range stats.Chars {
+		key := keyOf(char)
+		merged, ok := keys[key]
+
+		if !ok {
+			merged = &engine.KeyStat{}
+			keys[key] = merged
+		}
+
+		merged.Attempts += stat.Attempts
+		merged.Errors += stat.Errors
+		merged.Latency += stat.Latency
+		merged.Latencies += stat.Latencies
+	}
+
+	return keys
+}
+
+// heatmapMetric is what the keys of the heatmap are coloured by.
+type heatmapMetric int
+
+const (
+	heatmapErrors heatmapMetric = iota
+	heatmapLatency
+)
+
+func (m heatmapMetric) value(stat *engine.KeyStat) float64 {
+	if m == heatmapLatency {
+		return float64(stat.AvgLatency())
+	}
+
+	return stat.ErrorRate()
+}
+
+func (m heatmapMetric) String() string {
+	if m == heatmapLatency {
+		return "latency"
+	}
+
+	return "error rate"
+}
+
+// heatColors go from the best to the worst keys.
+var heatColors = []string{ "22", "28", "64", "100", "136", "166", "160", "124" }
+
+// the indent of each row of keys, like the stagger of a keyboard
+var rowIndents = []int{ 0, 2, 3, 4 }
+
+// renderHeatmap draws the layout with every key coloured by metric relative
+// to the worst key. Keys that were never typed are left uncoloured.
+func renderHeatmap(layout KeyboardLayout, keys map[rune]*engine.KeyStat, metric heatmapMetric) string {
+	worst := 0.0
+
+	for _, stat := range keys {
+		if stat.Attempts > 0 {
+			worst = max(worst, metric.value(stat))
+		}
+	}
+
+	render := func(key rune, label string) string {
+		stat, ok := keys[key]
+
+		if !ok || stat.Attempts == 0 {
+			return label
+		}
+
+		idx := 0
+
+		if worst > 0 {
+			idx = int(metric.value(stat)/worst*float64(len(heatColors) - 1) + 0.5)
+		}
+
+		return lipgloss.NewStyle().Background(lipgloss.Color(heatColors[idx])).Render(label)
+	}
+
+	builder := &strings.Builder{}
+
+	for i, row := range layout {
+		builder.WriteString(strings.Repeat(" ", rowIndents[min(i, len(rowIndents) - 1)]))
+
+		for _, key := range row {
+			builder.WriteString(render(key, " " + string(key) + " "))
+		}
+
+		builder.WriteRune('\n')
+	}
+
+	fmt.Fprintf(builder, "%s%s\n", strings.Repeat(" ", 12), render(' ', strings.Repeat(" ", 8) + "space" + strings.Repeat(" ", 8)))
+
+	return builder.String()
+}
+
+// visibleKey replaces the space in bigrams and keys so it can be seen.
+func visibleKey(s string) string {
+	return strings.ReplaceAll(s, " ", "␣")
+}
+
+// KeyboardModel is the page of the statistics screen showing how well every
+// key and bigram is typed.
+type KeyboardModel struct {
+	stats *engine.KeyStats
+	filter StatsFilter
+	testNames []string
+	layout KeyboardLayout
+	metric heatmapMetric
+	err error
+}
+
+func NewKeyboardModel(config *Config2, testNames []string) *KeyboardModel {
+	layout, err := config.KeyboardLayout()
+
+	return &KeyboardModel{
+		layout: layout,
+		testNames: testNames,
+		err: err,
+	}
+}
+
+func (m *KeyboardModel) nextTestName() {
+	m.filter.TestName = nextFilterValue(append([]string{ "" }, m.testNames...), m.filter.TestName)
+}
+
+func (m *KeyboardModel) nextMode() {
+	m.filter.Mode = nextFilterValue(dashboardModes, m.filter.Mode)
+}
+
+func (m *KeyboardModel) nextMetric() {
+	m.metric = (m.metric + 1) % 2
+}
+
+func (m *KeyboardModel) render() string {
+	builder := &strings.Builder{}
+
+	fmt.Fprintf(builder, "keys  words: %s  mode: %s  colour: %s\n\n", filterLabel(m.filter.TestName), filterLabel(m.filter.Mode), m.metric)
+
+	if m.err != nil {
+		fmt.Fprintf(builder, "error: %v\n", m.err)
+	}
+
+	if m.stats == nil {
+		builder.WriteString("loading...\n")
+		return builder.String()
+	}
+
+	if len(m.stats.Chars) == 0 {
+		builder.WriteString("no keystrokes recorded yet\n")
+		return builder.String()
+	}
+
+	builder.WriteString(renderHeatmap(m.layout, keyStats(m.stats), m.metric))
+	builder.WriteRune('\n')
+
+	bigrams := worstBigrams(m.stats, worstBigramsSize)
+
+	if len(bigrams) == 0 {
+		return builder.String()
+	}
+
+	fmt.Fprintf(builder, "worst bigrams\n%-8s %8s %8s %8s %10s\n", "bigram", "typed", "errors", "rate", "latency")
+
+	for _, b := range bigrams {
+		fmt.Fprintf(
+			builder,
+			"%-8s %8d %8d %7.1f%% %10s\n",
+			visibleKey(b.Bigram),
+			b.Attempts,
+			b.Errors,
+			b.ErrorRate()*100,
+			b.AvgLatency().Round(time.Millisecond),
+		)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,158 @@
+package racer
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+)
+
+func keystrokeTest(target string, typed string) *RacerTest {
+	s := engine.NewSession(target, engine.Options{ AllowBackspace: true })
+	at := time.Duration(0)
+
+	for _, char := range typed {
+		at += 100*time.Millisecond
+
+		if char == '<' {
+			s.Backspace(at)
+		} else {
+			s.Type(char, at)
+		}
+	}
+
+	test := storeTest(50, 1, false)
+	test.Mode = "time"
+	test.Keystrokes = s.Keystrokes()
+
+	return test
+}
+
+func TestGetKeyStats(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		words := keystrokeTest("the them", "the thxm")
+		words.Mode = "words"
+
+		for _, test := range []*RacerTest{ keystrokeTest("the then", "tge<he then"), words } {
+			if err := store.InsertTest(test); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+
+		want := engine.NewKeyStats()
+		want.Add(keystrokeTest("the then", "tge<he then").Keystrokes)
+
+		got, err := store.GetKeyStats(&StatsFilter{ Mode: "time" })
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(got.Chars) != len(want.Chars) || len(got.Bigrams) != len(want.Bigrams) {
+			t.Fatalf("%s: key stats got %d chars and %d bigrams wanted %d and %d", name, len(got.Chars), len(got.Bigrams), len(want.Chars), len(want.Bigrams))
+		}
+
+		for char, stat := range want.Chars {
+			if got := got.Chars[char]; got == nil || *got != *stat {
+				t.Errorf("%s: stats of %q got %+v wanted %+v", name, char, got, stat)
+			}
+		}
+
+		for bigram, stat := range want.Bigrams {
+			if got := got.Bigrams[bigram]; got == nil || *got != *stat {
+				t.Errorf("%s: stats of %q got %+v wanted %+v", name, bigram, got, stat)
+			}
+		}
+	}
+}
+
+func TestGetKeyStatsRejected(t *testing.T) {
+	s := engine.NewSession("the", engine.Options{ Stop: engine.StopOnLetter })
+
+	for i, char := range "tgghe" {
+		s.Type(char, time.Duration(i + 1)*100*time.Millisecond)
+	}
+
+	want := engine.NewKeyStats()
+	want.Add(s.Keystrokes())
+
+	if h := want.Chars['h']; h.Attempts != 3 || h.Errors != 2 || h.Latencies != 1 {
+		t.Errorf("stats of rejected keys got %+v wanted 3 attempts, 2 errors and 1 latency", h)
+	}
+
+	for name, store := range newTestStores(t) {
+		test := storeTest(50, 1, false)
+		test.Mode = "time"
+		test.Keystrokes = s.Keystrokes()
+
+		if err := store.InsertTest(test); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		got, err := store.GetKeyStats(&StatsFilter{})
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		for char, stat := range want.Chars {
+			if got := got.Chars[char]; got == nil || *got != *stat {
+				t.Errorf("%s: stats of %q got %+v wanted %+v", name, char, got, stat)
+			}
+		}
+
+		if len(got.Bigrams) != len(want.Bigrams) {
+			t.Errorf("%s: bigrams got %v wanted %v", name, got.Bigrams, want.Bigrams)
+		}
+	}
+}
+
+func TestWorstBigrams(t *testing.T) {
+	stats := engine.NewKeyStats()
+	stats.Bigrams["th"] = &engine.KeyStat{ Attempts: 10, Errors: 1 }
+	stats.Bigrams["he"] = &engine.KeyStat{ Attempts: 10, Errors: 5 }
+	stats.Bigrams["en"] = &engine.KeyStat{ Attempts: 10, Errors: 1, Latency: 2*time.Second, Latencies: 10 }
+	stats.Bigrams["zq"] = &engine.KeyStat{ Attempts: 1, Errors: 1 }
+
+	got := worstBigrams(stats, 2)
+
+	if len(got) != 2 || got[0].Bigram != "he" || got[1].Bigram != "en" {
+		t.Errorf("worst bigrams got %+v wanted he and en", got)
+	}
+}
+
+func TestKeyboardLayout(t *testing.T) {
+	config := DefaultConfig2()
+
+	layout, err := config.KeyboardLayout()
+
+	if err != nil || layout[1][:6] != "qwerty" {
+		t.Errorf("default layout got %v %v", layout, err)
+	}
+
+	config.KeyboardLayoutName = "mine"
+	config.Layouts = map[string][]string{ "mine": { "abc" } }
+
+	if layout, err := config.KeyboardLayout(); err != nil || len(layout) != 1 {
+		t.Errorf("custom layout got %v %v", layout, err)
+	}
+
+	config.KeyboardLayoutName = "missing"
+
+	if _, err := config.KeyboardLayout(); err == nil {
+		t.Errorf("unknown layout accepted")
+	}
+
+	for char, want := range map[rune]rune{ 'A': 'a', '?': '/', 'q': 'q' } {
+		if got := keyOf(char); got != want {
+			t.Errorf("keyOf(%q) got %q wanted %q", char, got, want)
+		}
+	}
+
+	keys := map[rune]*engine.KeyStat{ 'q': { Attempts: 1 } }
+	heatmap := renderHeatmap(keyboardLayouts["qwerty"], keys, heatmapErrors)
+
+	if !strings.Contains(heatmap, " q  w ") || !strings.Contains(heatmap, "space") {
+		t.Errorf("heatmap got\n%s", heatmap)
+	}
+}
@@ -0,0 +1,303 @@
+package racer
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps everything in memory. It behaves like DuckDBStore and
+// is used by tests that should not touch the file system.
+type MemoryStore struct {
+	mu sync.Mutex
+	tests []*RacerTest
+	stats GameStats
+	player *PlayerInfo
+	reviews map[string]*ReviewWord
+	now func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{ reviews: make(map[string]*ReviewWord), now: time.Now }
+}
+
+func copyTest(test *RacerTest, keystrokes bool) *RacerTest {
+	c := *test
+	c.Keystrokes = nil
+
+	if keystrokes {
+		c.Keystrokes = slices.Clone(test.Keystrokes)
+	}
+
+	return &c
+}
+
+func (s *MemoryStore) InsertTest(test *RacerTest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	test.Id = len(s.tests) + 1
+
+	if test.CreatedAt.IsZero() {
+		test.CreatedAt = s.now()
+	}
+
+	s.tests = append(s.tests, copyTest(test, true))
+	return nil
+}
+
+func (s *MemoryStore) GetTest(id int) (*RacerTest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id <= 0 || id > len(s.tests) {
+		return nil, fmt.Errorf("%w: %d", ErrTestNotFound, id)
+	}
+
+	return copyTest(s.tests[id-1], true), nil
+}
+
+func (s *MemoryStore) GetAllTests() ([]*RacerTest, error) {
+	page, err := s.GetTests(recentTestsQuery())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Tests, nil
+}
+
+// bestParams returns the parameters that identify the configuration test
+// was taken with.
+func (test *RacerTest) bestParams() *PersonalBestParams {
+	return &PersonalBestParams{
+		testName: test.Test,
+		mode: test.Mode,
+		testDuration: test.Time,
+		testSize: test.TestSize,
+		quoteLength: test.QuoteLength,
+		allowBackspace: test.AllowBackspace,
+		punctuation: test.Punctuation,
+		numbers: test.Numbers,
+		sampling: test.Sampling,
+		samplingSize: test.SamplingSize,
+		lazy: test.Lazy,
+		stopOnError: test.StopOnError,
+		adaptive: test.Adaptive,
+	}
+}
+
+// bestKey is comparable and equal for params describing the same test
+// configuration.
+type bestKey struct {
+	testName string
+	mode string
+	size int
+	quoteLength string
+	allowBackspace bool
+	punctuation bool
+	numbers bool
+	sampling string
+	samplingSize int
+	lazy bool
+	stopOnError string
+	adaptive string
+}
+
+func (p *PersonalBestParams) key() bestKey {
+	return bestKey{ p.testName, p.mode, p.size(), p.quoteLength, p.allowBackspace, p.punctuation, p.numbers, cmp.Or(p.sampling, samplingUniform), p.samplingSize, p.lazy, cmp.Or(p.stopOnError, defaultStopOnError), cmp.Or(p.adaptive, defaultAdaptive) }
+}
+
+func (p *PersonalBestParams) matches(test *RacerTest) bool {
+	key := test.bestParams().key()
+
+	if p.quoteLength == "all" {
+		key.quoteLength = p.quoteLength
+	}
+
+	return key == p.key()
+}
+
+func (s *MemoryStore) GetBestTest(params *PersonalBestParams) (*RacerTest, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var best *RacerTest
+
+	for _, test := range s.tests {
+		if !params.matches(test) || len(test.Keystrokes) == 0 {
+			continue
+		}
+
+		if best == nil || test.Wpm > best.Wpm {
+			best = test
+		}
+	}
+
+	if best == nil {
+		return nil, false, nil
+	}
+
+	return copyTest(best, true), true, nil
+}
+
+func (s *MemoryStore) personalBests(params *PersonalBestParams) []*PersonalBest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bests := make(map[bestKey]*PersonalBest)
+
+	for _, test := range s.tests {
+		if params != nil && !params.matches(test) {
+			continue
+		}
+
+		key := test.bestParams().key()
+		best, ok := bests[key]
+
+		if !ok {
+			best = &PersonalBest{
+				TestName: test.Test,
+				Mode: test.Mode,
+				Size: key.size,
+				QuoteLength: test.QuoteLength,
+				AllowBackspace: test.AllowBackspace,
+				Punctuation: test.Punctuation,
+				Numbers: test.Numbers,
+				Sampling: key.sampling,
+				SamplingSize: test.SamplingSize,
+				Lazy: test.Lazy,
+				StopOnError: key.stopOnError,
+				Adaptive: key.adaptive,
+				Wpm: -1,
+			}
+			bests[key] = best
+		}
+
+		best.Attempts++
+
+		if test.Wpm > best.Wpm {
+			best.TestId = test.Id
+			best.Wpm = test.Wpm
+			best.Accuracy = test.Accuracy
+			best.CreatedAt = test.CreatedAt
+		}
+	}
+
+	out := make([]*PersonalBest, 0, len(bests))
+
+	for _, best := range bests {
+		out = append(out, best)
+	}
+
+	slices.SortFunc(out, func(a, b *PersonalBest) int {
+		return cmp.Or(
+			cmp.Compare(a.TestName, b.TestName),
+			cmp.Compare(a.Mode, b.Mode),
+			cmp.Compare(a.Size, b.Size),
+			cmp.Compare(a.QuoteLength, b.QuoteLength),
+			compareBool(a.AllowBackspace, b.AllowBackspace),
+			compareBool(a.Punctuation, b.Punctuation),
+			compareBool(a.Numbers, b.Numbers),
+			cmp.Compare(a.Sampling, b.Sampling),
+			cmp.Compare(a.SamplingSize, b.SamplingSize),
+			compareBool(a.Lazy, b.Lazy),
+			cmp.Compare(a.StopOnError, b.StopOnError),
+			cmp.Compare(a.Adaptive, b.Adaptive),
+		)
+	})
+
+	return out
+}
+
+func compareBool(a, b bool) int {
+	if a == b {
+		return 0
+	}
+
+	if b {
+		return -1
+	}
+
+	return 1
+}
+
+func (s *MemoryStore) GetPersonalBests() ([]*PersonalBest, error) {
+	return s.personalBests(nil), nil
+}
+
+func (s *MemoryStore) GetPersonalBest(params *PersonalBestParams) (*PersonalBest, bool, error) {
+	bests := s.personalBests(params)
+
+	if len(bests) == 0 {
+		return nil, false, nil
+	}
+
+	return bests[0], true, nil
+}
+
+func (s *MemoryStore) HasImportedTest(source, externalId string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, test := range s.tests {
+		if test.Source == source && test.ExternalId == externalId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *MemoryStore) GetGameStats() (*GameStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats.Copy(), nil
+}
+
+func (s *MemoryStore) UpdateGameStats(stats *GameStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats = *stats.Copy()
+	return nil
+}
+
+func (s *MemoryStore) GetPlayerInfo() (*PlayerInfo, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.player == nil {
+		return nil, false, nil
+	}
+
+	info := *s.player
+	return &info, true, nil
+}
+
+func (s *MemoryStore) InsertPlayerInfo(params *PlayerInfoInsertParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.player != nil {
+		return fmt.Errorf("player info already exists")
+	}
+
+	s.player = &PlayerInfo{
+		name: params.name,
+		level: params.level,
+		maxHp: params.maxHp,
+		curHp: params.curHp,
+		wpm: params.wpm,
+		bossesDefeated: params.bossesDefeated,
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
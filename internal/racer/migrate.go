@@ -0,0 +1,225 @@
+package racer
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of racer")
+
+const createSchemaMigrationsTableQuery = `
+	CREATE TABLE IF NOT EXISTS schema_migrations(
+		version INTEGER PRIMARY KEY,
+		name VARCHAR NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// Migration is one step of the database schema. Migrations are stored in
+// migrations/ as <version>_<name>.sql and applied in version order. Once
+// released a migration must never change, new schema changes get a new
+// file with the next version.
+type Migration struct {
+	Version int
+	Name string
+	Query string
+}
+
+// MigrationStatus reports whether a migration has been applied to a
+// database.
+type MigrationStatus struct {
+	Migration
+	Applied bool
+	AppliedAt time.Time
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".sql")
+
+		if !ok {
+			continue
+		}
+
+		prefix, name, ok := strings.Cut(name, "_")
+
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+
+		version, err := strconv.Atoi(prefix)
+
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		query, err := fs.ReadFile(fsys, "migrations/" + entry.Name())
+
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name: name,
+			Query: string(query),
+		})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+
+	for i, m := range migrations {
+		if m.Version != i + 1 {
+			return nil, fmt.Errorf("migration %d_%s is out of sequence", m.Version, m.Name)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Migrations returns the migrations built into racer ordered by version.
+func Migrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles)
+}
+
+func appliedMigrations(db *sql.DB) (map[int]time.Time, error) {
+	if _, err := db.Exec(createSchemaMigrationsTableQuery); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// GetMigrationStatus lists every migration built into racer and whether it
+// has been applied to db.
+func GetMigrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.Version]
+
+		statuses = append(statuses, MigrationStatus{
+			Migration: m,
+			Applied: ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Migrate applies the migrations db is missing in order and returns the
+// ones that were applied. Every migration runs in its own transaction
+// together with the row recording it.
+func Migrate(db *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for version := range applied {
+		if version > len(migrations) {
+			return nil, fmt.Errorf("%w: version %d", ErrSchemaTooNew, version)
+		}
+	}
+
+	var done []Migration
+
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		if err := applyMigration(db, m); err != nil {
+			return done, fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+func applyMigration(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(m.Query); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES(?, ?)", m.Version, m.Name); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
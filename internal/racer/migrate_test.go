@@ -0,0 +1,214 @@
+package racer
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+)
+
+// baselineSchema is the schema created by racer before migrations were
+// tracked. Databases in the wild look like this.
+var baselineSchema = []string{
+	`CREATE SEQUENCE IF NOT EXISTS seq_test_id START 1;`,
+	`CREATE TABLE IF NOT EXISTS game_stats(
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		total INTEGER,
+		total_completed INTEGER,
+		total_attempted INTEGER,
+		last_test_id INTEGER
+	)`,
+	`CREATE TABLE IF NOT EXISTS all_tests(
+		id INTEGER PRIMARY KEY DEFAULT nextval('seq_test_id'),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		test_name VARCHAR,
+		test_duration INTEGER,
+		test_size INTEGER,
+		accuracy DOUBLE,
+		mode VARCHAR,
+		allow_backspace BOOLEAN,
+		target VARCHAR,
+		input VARCHAR,
+		wpm INTEGER,
+		cps INTEGER,
+		rle VARCHAR,
+		raw_input VARCHAR,
+		sample_rate INTEGER,
+		acc_samples DOUBLE[],
+		cps_samples INTEGER[],
+		wpm_samples INTEGER[]
+	)`,
+	`CREATE TABLE IF NOT EXISTS player_info(
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		name VARCHAR NOT NULL,
+		level INTEGER,
+		max_hp INTEGER,
+		cur_hp INTEGER,
+		wpm INTEGER,
+		bosses_defeated INTEGER
+	)`,
+}
+
+func TestMigrateFromBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "racer.db")
+
+	db, err := OpenDB(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range baselineSchema {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = db.Exec("INSERT INTO all_tests (test_name, test_duration, test_size, accuracy, mode, allow_backspace, target, input, wpm, cps, rle, raw_input) VALUES('english', 30, 500, 1.0, 'time', false, 'old test', 'old test', 40, 3, '', '')")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.Close()
+
+	db, err = SetupDB(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	statuses, err := GetMigrationStatus(db)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range statuses {
+		if !s.Applied {
+			t.Errorf("migration %d_%s was not applied", s.Version, s.Name)
+		}
+	}
+
+	old, err := GetRacerTest(db, 1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if old.Target != "old test" || old.Wpm != 40 || old.Seed != 0 {
+		t.Errorf("baseline test changed by migration got %+v", old)
+	}
+
+	test := &RacerTest{
+		Test: "english",
+		Time: 30,
+		TestSize: 500,
+		Seed: 1<<63 + 1,
+		Mode: "time",
+		Punctuation: true,
+		Target: "ab",
+		Input: "ab",
+		Wpm: 60,
+		RawWpm: 62,
+		Keystrokes: []engine.Keystroke{
+			{ Offset: time.Second, Expected: 'a', Typed: 'a', Op: engine.OpMatch },
+			{ Offset: 2*time.Second, Expected: 'b', Typed: 'b', Op: engine.OpMatch },
+		},
+	}
+
+	tx, err := db.Begin()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := InsertRacerTestTx(tx, test); err != nil {
+		tx.Rollback()
+		t.Fatal(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetRacerTest(db, test.Id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Seed != test.Seed || got.RawWpm != test.RawWpm || !got.Punctuation || len(got.Keystrokes) != 2 {
+		t.Errorf("migrated database lost columns got %+v wanted %+v", got, test)
+	}
+}
+
+func TestMigrateTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "racer.db")
+
+	db, err := SetupDB(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.Close()
+
+	db, err = OpenDB(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	applied, err := Migrate(db)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != 0 {
+		t.Errorf("migrations applied twice got %d wanted 0", len(applied))
+	}
+
+	if _, err := db.Exec("INSERT INTO schema_migrations (version, name) VALUES(1000, 'future')"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(db); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("migrating a newer schema got error %v wanted %v", err, ErrSchemaTooNew)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_second.sql": { Data: []byte("SELECT 2") },
+		"migrations/0001_first.sql": { Data: []byte("SELECT 1") },
+		"migrations/README": { Data: []byte("ignored") },
+	}
+
+	migrations, err := loadMigrations(fsys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Version != 2 {
+		t.Errorf("migrations not loaded in order got %+v", migrations)
+	}
+
+	fsys["migrations/0004_gap.sql"] = &fstest.MapFile{ Data: []byte("SELECT 4") }
+
+	if _, err := loadMigrations(fsys); err == nil {
+		t.Errorf("migrations with a gap in their versions accepted")
+	}
+
+	if _, err := Migrations(); err != nil {
+		t.Errorf("built in migrations are invalid: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+-- the schema racer shipped before migrations were tracked. Every statement
+-- is a no-op on databases that were created by older versions.
+CREATE SEQUENCE IF NOT EXISTS seq_test_id START 1;
+
+CREATE TABLE IF NOT EXISTS game_stats(
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	total INTEGER,
+	total_completed INTEGER,
+	total_attempted INTEGER,
+	last_test_id INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS all_tests(
+	id INTEGER PRIMARY KEY DEFAULT nextval('seq_test_id'),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	test_name VARCHAR,
+	test_duration INTEGER,
+	test_size INTEGER,
+	accuracy DOUBLE,
+	mode VARCHAR,
+	allow_backspace BOOLEAN,
+	target VARCHAR,
+	input VARCHAR,
+	wpm INTEGER,
+	cps INTEGER,
+	rle VARCHAR,
+	raw_input VARCHAR,
+	sample_rate INTEGER,
+	acc_samples DOUBLE[],
+	cps_samples INTEGER[],
+	wpm_samples INTEGER[]
+);
+
+CREATE TABLE IF NOT EXISTS player_info(
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	name VARCHAR NOT NULL,
+	level INTEGER,
+	max_hp INTEGER,
+	cur_hp INTEGER,
+	wpm INTEGER,
+	bosses_defeated INTEGER
+);
@@ -0,0 +1,8 @@
+-- columns added to all_tests for raw wpm, quotes, modifiers and seeded
+-- tests. Seeds are stored bit for bit in a signed column since
+-- database/sql does not accept uint64 values with the high bit set.
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS raw_wpm INTEGER;
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS quote_id INTEGER;
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS punctuation BOOLEAN;
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS numbers BOOLEAN;
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS seed BIGINT;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS keystrokes(
+	test_id INTEGER NOT NULL,
+	seq INTEGER NOT NULL,
+	offset_us BIGINT NOT NULL,
+	expected VARCHAR,
+	typed VARCHAR,
+	op VARCHAR NOT NULL,
+	PRIMARY KEY (test_id, seq)
+);
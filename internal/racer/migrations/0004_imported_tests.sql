@@ -0,0 +1,6 @@
+-- tests imported from other typing sites remember where they came from so
+-- importing the same file twice does not duplicate them. Tests taken in
+-- racer leave both columns null.
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS source VARCHAR;
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS external_id VARCHAR;
+CREATE UNIQUE INDEX IF NOT EXISTS all_tests_external_id ON all_tests (source, external_id);
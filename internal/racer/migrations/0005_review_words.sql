@@ -0,0 +1,11 @@
+-- words that were mistyped are reviewed on an SM-2 schedule. interval_days
+-- is the time until the word is due again after its last review.
+CREATE TABLE IF NOT EXISTS review_words(
+	word VARCHAR PRIMARY KEY,
+	ease DOUBLE NOT NULL,
+	interval_days INTEGER NOT NULL,
+	repetitions INTEGER NOT NULL,
+	lapses INTEGER NOT NULL,
+	due_at TIMESTAMP NOT NULL,
+	reviewed_at TIMESTAMP NOT NULL
+);
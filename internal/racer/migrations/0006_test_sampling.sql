@@ -0,0 +1,6 @@
+-- how the words of a test were sampled, tests are only comparable with
+-- tests sampled the same way. Older tests were sampled uniformly without
+-- lazy mode and leave the columns null.
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS sampling VARCHAR;
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS sampling_size INTEGER;
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS lazy BOOLEAN;
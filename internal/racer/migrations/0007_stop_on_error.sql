@@ -0,0 +1,4 @@
+-- the stop on error mode a test was taken with. Replays need it to accept
+-- the deletions stop on word allows without backspace. Older tests were
+-- taken without a stop mode and leave the column null.
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS stop_on_error VARCHAR;
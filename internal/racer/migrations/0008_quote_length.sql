@@ -0,0 +1,4 @@
+-- the length category of the quote typed in quote mode, quotes of
+-- different lengths are not comparable. Older quote tests and the tests
+-- of the other modes leave it null.
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS quote_length VARCHAR;
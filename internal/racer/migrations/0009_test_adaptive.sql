@@ -0,0 +1,4 @@
+-- the adaptive intensity a test was weighted with. Adaptive tests favour
+-- the weakest keys and are not comparable with the tests taken without
+-- it. Older tests leave the column null.
+ALTER TABLE all_tests ADD COLUMN IF NOT EXISTS adaptive VARCHAR;
@@ -0,0 +1,120 @@
+package racer
+
+import (
+	"math/rand/v2"
+	"strings"
+	"unicode"
+)
+
+// punctuation marks that can follow a word along with the weight they are
+// chosen with. Marks that end a sentence capitalize the next word.
+var trailingPunctuation = []struct{
+	mark string
+	weight int
+	endsSentence bool
+}{
+	{ ",", 10, false },
+	{ ".", 8, true },
+	{ "?", 2, true },
+	{ "!", 2, true },
+	{ ";", 2, false },
+	{ ":", 1, false },
+}
+
+const (
+	trailingPunctuationChance = 30
+	wrapPunctuationChance = 4
+	numberChance = 12
+	maxNumberLen = 4
+)
+
+func capitalize(word string) string {
+	runes := []rune(word)
+
+	if len(runes) == 0 {
+		return word
+	}
+
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func pickTrailingPunctuation(rng *rand.Rand) (string, bool) {
+	total := 0
+
+	for _, p := range trailingPunctuation {
+		total += p.weight
+	}
+
+	n := rng.IntN(total)
+
+	for _, p := range trailingPunctuation {
+		if n < p.weight {
+			return p.mark, p.endsSentence
+		}
+		n -= p.weight
+	}
+
+	return ".", true
+}
+
+// applyPunctuation turns a list of words into sentences. Sentences start
+// with a capital letter and end with a period, question or exclamation
+// mark, and words are occasionally followed by commas and other marks or
+// wrapped in quotes and parentheses.
+func applyPunctuation(rng *rand.Rand, words []string) []string {
+	out := make([]string, 0, len(words))
+	sentenceStart := true
+
+	for i, word := range words {
+		if sentenceStart {
+			word = capitalize(word)
+			sentenceStart = false
+		}
+
+		switch n := rng.IntN(100); {
+		case n < wrapPunctuationChance/2:
+			word = "\"" + word + "\""
+		case n < wrapPunctuationChance:
+			word = "(" + word + ")"
+		}
+
+		if i == len(words)-1 {
+			word += "."
+		} else if rng.IntN(100) < trailingPunctuationChance {
+			mark, endsSentence := pickTrailingPunctuation(rng)
+			word += mark
+			sentenceStart = endsSentence
+		}
+
+		out = append(out, word)
+	}
+
+	return out
+}
+
+func randomNumber(rng *rand.Rand) string {
+	builder := &strings.Builder{}
+	n := rng.IntN(maxNumberLen) + 1
+
+	for range n {
+		builder.WriteByte(byte('0' + rng.IntN(10)))
+	}
+
+	return builder.String()
+}
+
+// applyNumbers replaces some of the words with groups of digits.
+func applyNumbers(rng *rand.Rand, words []string) []string {
+	out := make([]string, 0, len(words))
+
+	for _, word := range words {
+		if rng.IntN(100) < numberChance {
+			word = randomNumber(rng)
+		}
+
+		out = append(out, word)
+	}
+
+	return out
+}
@@ -0,0 +1,56 @@
+package racer
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestApplyPunctuation(t *testing.T) {
+	words := strings.Fields(strings.Repeat("alpha beta gamma delta ", 50))
+
+	out := applyPunctuation(newRand(1), words)
+
+	if len(out) != len(words) {
+		t.Fatalf("incorrect number of words got %d wanted %d", len(out), len(words))
+	}
+
+	if first := strings.TrimLeft(out[0], "\"("); !unicode.IsUpper(rune(first[0])) {
+		t.Errorf("first word is not capitalized got %s", out[0])
+	}
+
+	if last := out[len(out)-1]; !strings.HasSuffix(last, ".") {
+		t.Errorf("last word does not end a sentence got %s", last)
+	}
+
+	for i := 1; i < len(out); i++ {
+		prev := strings.TrimRight(out[i-1], "\")")
+		word := strings.TrimLeft(out[i], "\"(")
+
+		if strings.HasSuffix(prev, ".") && !unicode.IsUpper(rune(word[0])) {
+			t.Errorf("word after the end of a sentence is not capitalized got %s %s", out[i-1], out[i])
+		}
+	}
+}
+
+func TestApplyNumbers(t *testing.T) {
+	words := strings.Fields(strings.Repeat("alpha ", 200))
+
+	out := applyNumbers(newRand(1), words)
+	numbers := 0
+
+	for _, word := range out {
+		if word == "alpha" {
+			continue
+		}
+
+		if strings.Trim(word, "0123456789") != "" || len(word) > maxNumberLen {
+			t.Errorf("invalid digit group %s", word)
+		}
+		numbers++
+	}
+
+	if numbers == 0 {
+		t.Errorf("no digit groups were inserted")
+	}
+}
@@ -0,0 +1,158 @@
+package racer
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"path/filepath"
+)
+
+// RACER_HOME points at a directory that holds the config file and all of
+// the data of one profile.
+const racerHomeEnv = "RACER_HOME"
+
+const appName = "go-racer"
+
+var (
+	legacyConfigDir = os.ExpandEnv("$HOME/.go-racer")
+	legacyConfigPath = filepath.Join(legacyConfigDir, "config.json")
+	legacyDataDir = filepath.Join(legacyConfigDir, "data")
+)
+
+// Paths are the locations racer reads and writes its files. The quotes and
+// intro text live below DataDir.
+type Paths struct {
+	ConfigFile string
+	DataDir string
+	WordsDir string
+	DbFile string
+}
+
+// resolvePaths works out where racer keeps its files. Locations set on
+// the command line win over the ones in the config file, which win over
+// the defaults. The config file itself can only be moved by RACER_HOME or
+// the command line.
+func resolvePaths(defaults Paths, config *Config2, flags Paths) Paths {
+	paths := defaults.merge(Paths{
+		DataDir: config.DataDir,
+		WordsDir: config.WordsDir,
+		DbFile: config.DbPath,
+	})
+
+	return paths.merge(flags).withDefaults()
+}
+
+// defaultPaths returns the locations used when nothing is configured.
+// RACER_HOME wins, then an existing ~/.go-racer so older installs keep
+// their data, then the XDG base directories on linux. Only the config file
+// and the data dir are set, everything else is derived from the data dir
+// once all settings are merged.
+func defaultPaths() (Paths, error) {
+	if home := os.Getenv(racerHomeEnv); home != "" {
+		return homePaths(expandPath(home)), nil
+	}
+
+	userHome, err := getHomeDir()
+
+	if err != nil {
+		return Paths{}, err
+	}
+
+	legacyDir := filepath.Join(userHome, ".go-racer")
+
+	exists, err := checkIfDirExists(legacyDir)
+
+	if err != nil {
+		return Paths{}, err
+	}
+
+	if exists || runtime.GOOS != "linux" {
+		return homePaths(legacyDir), nil
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+
+	if configHome == "" {
+		configHome = filepath.Join(userHome, ".config")
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+
+	if dataHome == "" {
+		dataHome = filepath.Join(userHome, ".local", "share")
+	}
+
+	paths := Paths{
+		ConfigFile: filepath.Join(configHome, appName, "config.toml"),
+		DataDir: filepath.Join(dataHome, appName),
+	}
+
+	return paths, nil
+}
+
+func homePaths(dir string) Paths {
+	return Paths{
+		ConfigFile: filepath.Join(dir, "config.toml"),
+		DataDir: dir,
+	}
+}
+
+// merge returns p with every location that is set in o replaced.
+func (p Paths) merge(o Paths) Paths {
+	if o.ConfigFile != "" {
+		p.ConfigFile = expandPath(o.ConfigFile)
+	}
+
+	if o.DataDir != "" {
+		p.DataDir = expandPath(o.DataDir)
+	}
+
+	if o.WordsDir != "" {
+		p.WordsDir = expandPath(o.WordsDir)
+	}
+
+	if o.DbFile != "" {
+		p.DbFile = expandPath(o.DbFile)
+	}
+
+	return p
+}
+
+// withDefaults fills in the locations that are derived from DataDir.
+func (p Paths) withDefaults() Paths {
+	if p.WordsDir == "" {
+		p.WordsDir = filepath.Join(p.DataDir, "data")
+	}
+
+	if p.DbFile == "" {
+		p.DbFile = filepath.Join(p.DataDir, "racer.db")
+	}
+
+	return p
+}
+
+func (p Paths) QuotesDir() string {
+	return filepath.Join(p.DataDir, "quotes")
+}
+
+func (p Paths) IntroFile() string {
+	return filepath.Join(p.DataDir, "wuxia", "intro.txt")
+}
+
+// expandPath expands environment variables and a leading ~ in paths taken
+// from the config file, the environment or the command line.
+func expandPath(path string) string {
+	path = os.ExpandEnv(path)
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := getHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+
+	return filepath.Clean(path)
+}
+
+func ensureParentDir(path string) error {
+	return os.MkdirAll(filepath.Dir(path), 0777)
+}
@@ -0,0 +1,134 @@
+package racer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDefaultPaths(t *testing.T) {
+	home := t.TempDir()
+
+	t.Setenv("HOME", home)
+	t.Setenv(racerHomeEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "xdg-data"))
+
+	if runtime.GOOS == "linux" {
+		got, err := defaultPaths()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := Paths{
+			ConfigFile: filepath.Join(home, "xdg-config", "go-racer", "config.toml"),
+			DataDir: filepath.Join(home, "xdg-data", "go-racer"),
+		}
+
+		if got != want {
+			t.Errorf("xdg paths got %+v wanted %+v", got, want)
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(home, ".go-racer"), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := defaultPaths()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := homePaths(filepath.Join(home, ".go-racer")); got != want {
+		t.Errorf("legacy paths got %+v wanted %+v", got, want)
+	}
+
+	profile := filepath.Join(home, "profile")
+	t.Setenv(racerHomeEnv, profile)
+
+	got, err = defaultPaths()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := homePaths(profile); got != want {
+		t.Errorf("RACER_HOME paths got %+v wanted %+v", got, want)
+	}
+}
+
+func TestResolvePaths(t *testing.T) {
+	defaults := homePaths("/home/racer")
+
+	config := DefaultConfig2()
+	config.DataDir = "/sync/racer"
+	config.DbPath = "/fast/racer.db"
+
+	tests := []struct {
+		flags Paths
+		want Paths
+	}{
+		{
+			flags: Paths{},
+			want: Paths{
+				ConfigFile: "/home/racer/config.toml",
+				DataDir: "/sync/racer",
+				WordsDir: "/sync/racer/data",
+				DbFile: "/fast/racer.db",
+			},
+		},
+		{
+			flags: Paths{ DataDir: "/tmp/profile", WordsDir: "/tmp/words", DbFile: "/tmp/test.db" },
+			want: Paths{
+				ConfigFile: "/home/racer/config.toml",
+				DataDir: "/tmp/profile",
+				WordsDir: "/tmp/words",
+				DbFile: "/tmp/test.db",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if got := resolvePaths(defaults, config, test.flags); got != test.want {
+			t.Errorf("resolvePaths(%+v) got %+v wanted %+v", test.flags, got, test.want)
+		}
+	}
+}
+
+func TestReadOrCreateConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(racerHomeEnv, dir)
+
+	config, err := ReadOrCreateConfig(Paths{ DbFile: filepath.Join(dir, "db", "test.db") })
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := config.Paths()
+
+	for _, path := range []string{ paths.ConfigFile, paths.WordsDir, paths.QuotesDir(), paths.IntroFile() } {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s was not created: %v", path, err)
+		}
+	}
+
+	db, err := SetupDB(paths.DbFile)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "db", "test.db")); err != nil {
+		t.Errorf("database was not created at the configured path: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "racer.db")); err == nil {
+		t.Errorf("database was created at the default path")
+	}
+}
@@ -12,7 +12,7 @@ var (
 
 type PlayerInfoModel struct {
 	found bool
-	input []byte
+	input []rune
 	value string
 	idx int
 }
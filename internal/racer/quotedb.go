@@ -0,0 +1,116 @@
+package racer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"errors"
+	"math/rand/v2"
+)
+
+var (
+	ErrQuoteListNotFound = errors.New("quote list not found")
+	ErrNoQuotesFound = errors.New("no quotes found")
+)
+
+// Quote is a passage typed in quote mode. Length is one of short, medium
+// or long and is used to pick quotes of a similar size.
+type Quote struct {
+	Id int `json:"id"`
+	Text string `json:"text"`
+	Source string `json:"source"`
+	Length string `json:"length"`
+}
+
+type QuoteList struct {
+	Name string `json:"name"`
+	Quotes []*Quote `json:"quotes"`
+}
+
+type QuoteDb struct {
+	quoteLists map[string]*QuoteList
+}
+
+func LoadQuoteDb(dirPath string) (*QuoteDb, error) {
+	dirEntries, err := os.ReadDir(dirPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	db := make(map[string]*QuoteList)
+
+	for _, entry := range dirEntries {
+		file, err := os.Open(filepath.Join(dirPath, entry.Name()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		quoteList := &QuoteList{}
+		err = json.NewDecoder(file).Decode(quoteList)
+		file.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		db[quoteList.Name] = quoteList
+	}
+
+	quoteDb := &QuoteDb{
+		quoteLists: db,
+	}
+
+	return quoteDb, nil
+}
+
+func (q *QuoteDb) Get(name string) (*QuoteList, bool) {
+	l, ok := q.quoteLists[name]
+	return l, ok
+}
+
+// Filter returns the quotes of the given length category. The length "all"
+// matches every quote.
+func (l *QuoteList) Filter(length string) []*Quote {
+	if length == "all" {
+		return l.Quotes
+	}
+
+	var quotes []*Quote
+
+	for _, quote := range l.Quotes {
+		if quote.Length == length {
+			quotes = append(quotes, quote)
+		}
+	}
+
+	return quotes
+}
+
+func (l *QuoteList) GetById(id int) (*Quote, bool) {
+	for _, quote := range l.Quotes {
+		if quote.Id == id {
+			return quote, true
+		}
+	}
+
+	return nil, false
+}
+
+// Random picks a quote of the given length category from the named list.
+func (q *QuoteDb) Random(rng *rand.Rand, name string, length string) (*Quote, error) {
+	l, ok := q.Get(name)
+
+	if !ok {
+		return nil, ErrQuoteListNotFound
+	}
+
+	quotes := l.Filter(length)
+
+	if len(quotes) == 0 {
+		return nil, ErrNoQuotesFound
+	}
+
+	return quotes[rng.IntN(len(quotes))], nil
+}
@@ -0,0 +1,46 @@
+package racer
+
+import (
+	"testing"
+)
+
+func TestLoadQuoteDb(t *testing.T) {
+	quoteDb, err := LoadQuoteDb("quotes")
+
+	if err != nil {
+		t.Errorf("got error: %v", err)
+		t.FailNow()
+	}
+
+	l, ok := quoteDb.Get("english")
+
+	if !ok {
+		t.Fatalf("could not find quote list in db for key %s", "english")
+	}
+
+	for _, length := range []string{ "short", "medium", "long" } {
+		quotes := l.Filter(length)
+
+		if len(quotes) == 0 {
+			t.Errorf("no quotes found with length %s", length)
+		}
+
+		for _, quote := range quotes {
+			if quote.Length != length {
+				t.Errorf("quote %d has length %s wanted %s", quote.Id, quote.Length, length)
+			}
+
+			if quote.Source == "" {
+				t.Errorf("quote %d has no source", quote.Id)
+			}
+		}
+	}
+
+	if n := len(l.Filter("all")); n != len(l.Quotes) {
+		t.Errorf("incorrect number of quotes for length all got %d wanted %d", n, len(l.Quotes))
+	}
+
+	if _, err := quoteDb.Random(newRand(1), "missing", "all"); err != ErrQuoteListNotFound {
+		t.Errorf("incorrect error for missing quote list got %v", err)
+	}
+}
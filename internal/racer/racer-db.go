@@ -1,64 +1,20 @@
 package racer
 
 import (
+	"cmp"
 	"database/sql"
 	_ "github.com/marcboeker/go-duckdb/v2"
-	"path/filepath"
 	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+	"github.com/arjunmoola/go-racer/internal/engine"
 )
 
 const driverName = "duckdb"
 
-var defaultDbPath = filepath.Join(defaultConfigDir, "racer.db")
+var ErrTestNotFound = errors.New("test not found")
 
-const createRacerTestIdSeq = `
-	CREATE SEQUENCE IF NOT EXISTS seq_test_id START 1;
-`
-
-const createGameStatsTableQuery = `
-	CREATE TABLE IF NOT EXISTS game_stats(
-		id INTEGER PRIMARY KEY CHECK (id = 1),
-		total INTEGER,
-		total_completed INTEGER,
-		total_attempted INTEGER,
-		last_test_id INTEGER
-	)
-`
-
-const createTestsTableQuery = `
-	CREATE TABLE IF NOT EXISTS all_tests(
-		id INTEGER PRIMARY KEY DEFAULT nextval('seq_test_id'),
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		test_name VARCHAR,
-		test_duration INTEGER,
-		test_size INTEGER,
-		accuracy DOUBLE,
-		mode VARCHAR,
-		allow_backspace BOOLEAN,
-		target VARCHAR,
-		input VARCHAR,
-		wpm INTEGER,
-		cps INTEGER,
-		rle VARCHAR,
-		raw_input VARCHAR,
-		sample_rate INTEGER,
-		acc_samples DOUBLE[],
-		cps_samples INTEGER[],
-		wpm_samples INTEGER[]
-	)
-`
-
-const createPlayerInfoTableQuery =`
-	CREATE TABLE IF NOT EXISTS player_info(
-		id INTEGER PRIMARY KEY CHECK (id = 1),
-		name VARCHAR NOT NULL,
-		level INTEGER,
-		max_hp INTEGER,
-		cur_hp INTEGER,
-		wpm INTEGER,
-		bosses_defeated INTEGER
-	)
-`
 
 type RacerTestInsertParams struct {
 	testName string
@@ -94,12 +50,29 @@ type RacerTest struct {
 	Test string
 	Time int
 	TestSize int
+	QuoteId int
+	// QuoteLength is the length category of the quote of a quote test.
+	QuoteLength string
+	Seed uint64
 	Accuracy float64
 	Mode string
 	AllowBackspace bool
+	Punctuation bool
+	Numbers bool
+	// Sampling, SamplingSize and Lazy are how the words were drawn from the
+	// word list, an empty Sampling is uniform.
+	Sampling string
+	SamplingSize int
+	Lazy bool
+	// StopOnError is the stop mode of the test, empty is off.
+	StopOnError string
+	// Adaptive is the intensity the words of the test were weighted with,
+	// empty is off.
+	Adaptive string
 	Target string
 	Input string
 	Wpm int
+	RawWpm int
 	Cps int
 	Rle string
 	RawInput string
@@ -107,6 +80,64 @@ type RacerTest struct {
 	AccList []float64
 	CpsList []int
 	WpmList []int
+	Keystrokes []engine.Keystroke
+	CreatedAt time.Time
+	// Source and ExternalId identify tests imported from other sites.
+	Source string
+	ExternalId string
+}
+
+// PersonalBestParams identifies a test configuration. Tests taken with the
+// same parameters are comparable with each other. The quote length "all"
+// matches quotes of every length.
+type PersonalBestParams struct {
+	testName string
+	mode string
+	testDuration int
+	testSize int
+	quoteLength string
+	allowBackspace bool
+	punctuation bool
+	numbers bool
+	sampling string
+	samplingSize int
+	lazy bool
+	stopOnError string
+	adaptive string
+}
+
+func (p *PersonalBestParams) size() int {
+	if p.mode == "time" {
+		return p.testDuration
+	}
+
+	return p.testSize
+}
+
+func (p *PersonalBestParams) args() []any {
+	return []any{ p.testName, p.mode, p.allowBackspace, p.punctuation, p.numbers, p.size(), cmp.Or(p.sampling, samplingUniform), p.samplingSize, p.lazy, cmp.Or(p.stopOnError, defaultStopOnError), cmp.Or(p.adaptive, defaultAdaptive), p.quoteLength, p.quoteLength }
+}
+
+// PersonalBest is the highest wpm reached for a test configuration. Size is
+// the test duration in time mode and the number of words otherwise.
+type PersonalBest struct {
+	TestName string
+	Mode string
+	Size int
+	QuoteLength string
+	AllowBackspace bool
+	Punctuation bool
+	Numbers bool
+	Sampling string
+	SamplingSize int
+	Lazy bool
+	StopOnError string
+	Adaptive string
+	TestId int
+	Wpm int
+	Accuracy float64
+	CreatedAt time.Time
+	Attempts int
 }
 
 type PlayerInfo struct {
@@ -118,38 +149,38 @@ type PlayerInfo struct {
 	bossesDefeated int
 }
 
-func SetupDB(path string) (*sql.DB, error) {
-	db, err := sql.Open(driverName, defaultDbPath)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if err := db.Ping(); err != nil {
+// OpenDB opens the database at path without changing its schema. The
+// file and its directory are created when they do not exist yet.
+func OpenDB(path string) (*sql.DB, error) {
+	if err := ensureParentDir(path); err != nil {
 		return nil, err
 	}
 
-	_, err = db.Exec(createGameStatsTableQuery)
+	db, err := sql.Open(driverName, path)
 
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = db.Exec(createRacerTestIdSeq)
-
-	if err != nil {
+	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	_, err = db.Exec(createTestsTableQuery)
+	return db, nil
+}
+
+// SetupDB opens the database at path and applies any migrations it is
+// missing.
+func SetupDB(path string) (*sql.DB, error) {
+	db, err := OpenDB(path)
 
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = db.Exec(createPlayerInfoTableQuery)
-
-	if err != nil {
+	if _, err := Migrate(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
@@ -254,55 +285,127 @@ func UpdateGameStatsTx(tx *sql.Tx, stats *GameStats) error {
 	return nil
 }
 
-func prepareStatement(s string, db *sql.DB) (*sql.Stmt, error) {
-	stmt, err := db.Prepare(s)
+// toInt32s converts samples to the element type duckdb expects when binding
+// INTEGER[] parameters.
+func toInt32s(nums []int) []int32 {
+	out := make([]int32, 0, len(nums))
+
+	for _, num := range nums {
+		out = append(out, int32(num))
+	}
+
+	return out
+}
+
+// created_at is always bound in UTC. duckdb would cast CURRENT_TIMESTAMP to
+// the local wall clock time, which is read back as if it was UTC.
+const insertTestStmtStr = "INSERT INTO all_tests (test_name, test_duration, test_size, quote_id, seed, accuracy, mode, allow_backspace, punctuation, numbers, target, input, wpm, raw_wpm, cps, rle, raw_input, sample_rate, acc_samples, cps_samples, wpm_samples, source, external_id, sampling, sampling_size, lazy, stop_on_error, quote_length, adaptive, created_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at"
+
+// InsertRacerTest inserts the test and its keystrokes in a single
+// transaction. The id assigned to the test is written back to test.Id.
+func InsertRacerTest(db *sql.DB, test *RacerTest) error {
+	tx, err := db.Begin()
 
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if err := InsertRacerTestTx(tx, test); err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	return stmt, nil
+	return tx.Commit()
 }
 
-const insertTestStmtStr = "INSERT INTO all_tests (test_name, test_duration, test_size, accuracy, mode, allow_backspace, target, input, wpm, cps, rle, raw_input, sample_rate, acc_samples, cps_samples, wpm_samples) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+// createdAt is when test was taken, only imported tests come with a time,
+// everything else is taken at the time of the insert.
+func createdAt(test *RacerTest) time.Time {
+	if test.CreatedAt.IsZero() {
+		return time.Now()
+	}
+
+	return test.CreatedAt
+}
 
-func InsertRacerTestStmt(stmt *sql.Stmt, test *RacerTest) error {
-	_, err := stmt.Exec(
+func racerTestArgs(test *RacerTest) []any {
+	return []any{
 		test.Test,
 		test.Time,
 		test.TestSize,
+		test.QuoteId,
+		int64(test.Seed),
 		test.Accuracy,
 		test.Mode,
 		test.AllowBackspace,
+		test.Punctuation,
+		test.Numbers,
 		test.Target,
 		test.Input,
 		test.Wpm,
+		test.RawWpm,
 		test.Cps,
 		test.Rle,
 		test.RawInput,
 		test.SampleRate,
 		test.AccList,
-		test.CpsList,
-		test.WpmList,
-	)
+		toInt32s(test.CpsList),
+		toInt32s(test.WpmList),
+		sql.NullString{ String: test.Source, Valid: test.Source != "" },
+		sql.NullString{ String: test.ExternalId, Valid: test.Source != "" },
+		cmp.Or(test.Sampling, samplingUniform),
+		test.SamplingSize,
+		test.Lazy,
+		cmp.Or(test.StopOnError, defaultStopOnError),
+		sql.NullString{ String: test.QuoteLength, Valid: test.QuoteLength != "" },
+		cmp.Or(test.Adaptive, defaultAdaptive),
+		createdAt(test).UTC(),
+	}
+}
+
+const insertKeystrokeStmtStr = "INSERT INTO keystrokes (test_id, seq, offset_us, expected, typed, op) VALUES(?, ?, ?, ?, ?, ?)"
+
+func InsertKeystrokesTx(tx *sql.Tx, testId int, keystrokes []engine.Keystroke) error {
+	if len(keystrokes) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(insertKeystrokeStmtStr)
 
 	if err != nil {
 		return err
 	}
 
+	defer stmt.Close()
+
+	for seq, key := range keystrokes {
+		_, err := stmt.Exec(
+			testId,
+			seq,
+			key.Offset.Microseconds(),
+			string(key.Expected),
+			string(key.Typed),
+			key.Op,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// InsertRacerTestTx writes the same columns as InsertRacerTest along with
+// the keystrokes of the test as part of tx.
 func InsertRacerTestTx(tx *sql.Tx, test *RacerTest) error {
-	query := "INSERT INTO all_tests (test_name, test_duration, test_size, accuracy, mode, allow_backspace, target, input, wpm, cps) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	row := tx.QueryRow(insertTestStmtStr, racerTestArgs(test)...)
 
-	_, err := tx.Exec(query, test.Test, test.Time, test.TestSize, test.Accuracy, test.Mode, test.AllowBackspace, test.Target, test.Input, test.Wpm, test.Cps)
-
-	if err != nil {
+	if err := row.Scan(&test.Id, &test.CreatedAt); err != nil {
 		return err
 	}
 
-	return nil
+	return InsertKeystrokesTx(tx, test.Id, test.Keystrokes)
 }
 
 func GetTotalNumberOfTests(db *sql.DB) (int, error) {
@@ -319,19 +422,48 @@ func GetTotalNumberOfTests(db *sql.DB) (int, error) {
 	return count, nil
 }
 
-const getAllTestsQueryStr = `
+const getTestsQueryStr = `
 	SELECT
 		id, test_name, test_duration,
 		test_size, accuracy, mode,
-		allow_backspace, target, input,
-		wpm, cps, rle, raw_input
+		allow_backspace, coalesce(punctuation, false), coalesce(numbers, false),
+		coalesce(sampling, 'uniform'), coalesce(sampling_size, 0), coalesce(lazy, false),
+		coalesce(stop_on_error, 'off'), coalesce(adaptive, 'off'),
+		target, input,
+		wpm, coalesce(raw_wpm, 0), cps, coalesce(rle, ''), coalesce(raw_input, ''), created_at
 	FROM all_tests
-	ORDER BY id DESC
-	LIMIT 100
+	WHERE %s
+	ORDER BY %s %s, id %[3]s
+	%s
 	`
 
-func GetAllTests(stmt *sql.Stmt) ([]*RacerTest, error) {
-	rows, err := stmt.Query()
+const countTestsQueryStr = `
+	SELECT count(*)
+	FROM all_tests
+	WHERE %s
+	`
+
+// QueryTests returns the page of tests selected by query without their
+// keystrokes.
+func QueryTests(db *sql.DB, query *TestQuery) (*TestPage, error) {
+	where, args := query.Filter.where()
+	page := &TestPage{ Offset: query.Offset }
+
+	row := db.QueryRow(fmt.Sprintf(countTestsQueryStr, where), args...)
+
+	if err := row.Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	// the sort column comes from testColumns and the numbers are ours, so
+	// they are safe to format into the query.
+	var limit string
+
+	if query.Limit > 0 {
+		limit = fmt.Sprintf("LIMIT %d OFFSET %d", query.Limit, max(query.Offset, 0))
+	}
+
+	rows, err := db.Query(fmt.Sprintf(getTestsQueryStr, where, query.column().expr, query.direction(), limit), args...)
 
 	if err != nil {
 		return nil, err
@@ -339,8 +471,6 @@ func GetAllTests(stmt *sql.Stmt) ([]*RacerTest, error) {
 
 	defer rows.Close()
 
-	var tests []*RacerTest
-
 	for rows.Next() {
 		test := RacerTest{}
 
@@ -352,24 +482,310 @@ func GetAllTests(stmt *sql.Stmt) ([]*RacerTest, error) {
 			&test.Accuracy,
 			&test.Mode,
 			&test.AllowBackspace,
+			&test.Punctuation,
+			&test.Numbers,
+			&test.Sampling,
+			&test.SamplingSize,
+			&test.Lazy,
+			&test.StopOnError,
+			&test.Adaptive,
 			&test.Target,
 			&test.Input,
 			&test.Wpm,
+			&test.RawWpm,
 			&test.Cps,
 			&test.Rle,
 			&test.RawInput,
+			&test.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		page.Tests = append(page.Tests, &test)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetAllTests returns the 100 most recent tests without their keystrokes.
+func GetAllTests(db *sql.DB) ([]*RacerTest, error) {
+	page, err := QueryTests(db, recentTestsQuery())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Tests, nil
+}
+
+const getTestQueryStr = `
+	SELECT
+		id, test_name, test_duration,
+		test_size, coalesce(quote_id, 0), coalesce(quote_length, ''), coalesce(seed, 0), accuracy, mode,
+		allow_backspace, coalesce(punctuation, false), coalesce(numbers, false),
+		coalesce(sampling, 'uniform'), coalesce(sampling_size, 0), coalesce(lazy, false),
+		coalesce(stop_on_error, 'off'), coalesce(adaptive, 'off'),
+		target, input,
+		wpm, coalesce(raw_wpm, 0), cps, coalesce(rle, ''), coalesce(raw_input, ''), created_at
+	FROM all_tests
+	WHERE id = ?
+	`
+
+// GetRacerTest returns the test with the given id along with its recorded
+// keystrokes.
+func GetRacerTest(db *sql.DB, id int) (*RacerTest, error) {
+	row := db.QueryRow(getTestQueryStr, id)
+	test := RacerTest{}
+	var seed int64
+
+	err := row.Scan(
+		&test.Id,
+		&test.Test,
+		&test.Time,
+		&test.TestSize,
+		&test.QuoteId,
+		&test.QuoteLength,
+		&seed,
+		&test.Accuracy,
+		&test.Mode,
+		&test.AllowBackspace,
+		&test.Punctuation,
+		&test.Numbers,
+		&test.Sampling,
+		&test.SamplingSize,
+		&test.Lazy,
+		&test.StopOnError,
+		&test.Adaptive,
+		&test.Target,
+		&test.Input,
+		&test.Wpm,
+		&test.RawWpm,
+		&test.Cps,
+		&test.Rle,
+		&test.RawInput,
+		&test.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %d", ErrTestNotFound, id)
+		}
+		return nil, err
+	}
+
+	test.Seed = uint64(seed)
+
+	keystrokes, err := GetKeystrokes(db, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	test.Keystrokes = keystrokes
+
+	return &test, nil
+}
+
+const getKeystrokesQueryStr = "SELECT offset_us, expected, typed, op FROM keystrokes WHERE test_id = ? ORDER BY seq"
+
+func GetKeystrokes(db *sql.DB, testId int) ([]engine.Keystroke, error) {
+	rows, err := db.Query(getKeystrokesQueryStr, testId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var keystrokes []engine.Keystroke
+
+	for rows.Next() {
+		var offset int64
+		var expected, typed string
+		key := engine.Keystroke{}
+
+		if err := rows.Scan(&offset, &expected, &typed, &key.Op); err != nil {
+			return nil, err
+		}
+
+		key.Offset = time.Duration(offset)*time.Microsecond
+
+		key.Expected, _ = utf8.DecodeRuneInString(expected)
+		key.Typed, _ = utf8.DecodeRuneInString(typed)
+
+		keystrokes = append(keystrokes, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keystrokes, nil
+}
+
+// HasImportedTest reports whether the test with the given id has already
+// been imported from source.
+func HasImportedTest(db *sql.DB, source, externalId string) (bool, error) {
+	row := db.QueryRow("SELECT count(*) FROM all_tests WHERE source = ? AND external_id = ?", source, externalId)
+
+	var count int
+
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// testConfigWhereClause matches the tests taken with the parameters
+// returned by PersonalBestParams.args.
+const testConfigWhereClause = `
+	WHERE test_name = ?
+		AND mode = ?
+		AND allow_backspace = ?
+		AND coalesce(punctuation, false) = ?
+		AND coalesce(numbers, false) = ?
+		AND CASE WHEN mode = 'time' THEN test_duration ELSE test_size END = ?
+		AND coalesce(sampling, 'uniform') = ?
+		AND coalesce(sampling_size, 0) = ?
+		AND coalesce(lazy, false) = ?
+		AND coalesce(stop_on_error, 'off') = ?
+		AND coalesce(adaptive, 'off') = ?
+		AND (? = 'all' OR coalesce(quote_length, '') = ?)
+	`
+
+const getBestTestIdQueryStr = `
+	SELECT id
+	FROM all_tests
+	%s
+		AND EXISTS (SELECT 1 FROM keystrokes WHERE keystrokes.test_id = all_tests.id)
+	ORDER BY wpm DESC, id ASC
+	LIMIT 1
+	`
+
+// GetBestTest returns the highest wpm test with recorded keystrokes for the
+// given configuration.
+func GetBestTest(db *sql.DB, params *PersonalBestParams) (*RacerTest, bool, error) {
+	row := db.QueryRow(fmt.Sprintf(getBestTestIdQueryStr, testConfigWhereClause), params.args()...)
+
+	var id int
+
+	if err := row.Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	test, err := GetRacerTest(db, id)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return test, true, nil
+}
+
+const personalBestsQueryStr = `
+	SELECT
+		test_name, mode,
+		CASE WHEN mode = 'time' THEN test_duration ELSE test_size END AS size,
+		coalesce(quote_length, '') AS quote_length,
+		allow_backspace,
+		coalesce(punctuation, false) AS punctuation,
+		coalesce(numbers, false) AS numbers,
+		coalesce(sampling, 'uniform') AS sampling,
+		coalesce(sampling_size, 0) AS sampling_size,
+		coalesce(lazy, false) AS lazy,
+		coalesce(stop_on_error, 'off') AS stop_on_error,
+		coalesce(adaptive, 'off') AS adaptive,
+		arg_max(id, wpm), max(wpm), arg_max(accuracy, wpm), arg_max(created_at, wpm),
+		count(*)
+	FROM all_tests
+	%s
+	GROUP BY ALL
+	ORDER BY test_name, mode, size, quote_length, allow_backspace, punctuation, numbers, sampling, sampling_size, lazy, stop_on_error, adaptive
+	`
+
+func scanPersonalBests(rows *sql.Rows) ([]*PersonalBest, error) {
+	var bests []*PersonalBest
+
+	for rows.Next() {
+		best := PersonalBest{}
+
+		err := rows.Scan(
+			&best.TestName,
+			&best.Mode,
+			&best.Size,
+			&best.QuoteLength,
+			&best.AllowBackspace,
+			&best.Punctuation,
+			&best.Numbers,
+			&best.Sampling,
+			&best.SamplingSize,
+			&best.Lazy,
+			&best.StopOnError,
+			&best.Adaptive,
+			&best.TestId,
+			&best.Wpm,
+			&best.Accuracy,
+			&best.CreatedAt,
+			&best.Attempts,
 		)
 
 		if err != nil {
 			return nil, err
 		}
 
-		tests = append(tests, &test)
+		bests = append(bests, &best)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return tests, nil
+	return bests, nil
+}
+
+// GetPersonalBests returns the personal best of every test configuration
+// that has been attempted.
+func GetPersonalBests(db *sql.DB) ([]*PersonalBest, error) {
+	rows, err := db.Query(fmt.Sprintf(personalBestsQueryStr, ""))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanPersonalBests(rows)
+}
+
+// GetPersonalBest returns the personal best for a single test configuration.
+func GetPersonalBest(db *sql.DB, params *PersonalBestParams) (*PersonalBest, bool, error) {
+	rows, err := db.Query(fmt.Sprintf(personalBestsQueryStr, testConfigWhereClause), params.args()...)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer rows.Close()
+
+	bests, err := scanPersonalBests(rows)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(bests) == 0 {
+		return nil, false, nil
+	}
+
+	return bests[0], true, nil
 }
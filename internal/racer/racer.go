@@ -5,7 +5,6 @@ import (
 	"time"
 	//"io"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/bubbles/timer"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/lipgloss"
 	"strings"
@@ -13,9 +12,8 @@ import (
 	"fmt"
 	"slices"
 	//"golang.org/x/sync/errgroup"
-	"database/sql"
 	"github.com/arjunmoola/go-racer/internal/models/clock"
-	//"strconv"
+	"github.com/arjunmoola/go-racer/internal/engine"
 )
 
 var (
@@ -34,6 +32,19 @@ const (
 	RESULTS
 	STATISTICS
 	PLAYER_INFO
+	REPLAY
+	CHALLENGE
+)
+
+// statsView is the page of the statistics screen that is shown.
+type statsView int
+
+const (
+	statsDashboard statsView = iota
+	statsAllTests
+	statsBests
+	statsKeys
+	numStatsViews
 )
 
 type teaUpdateFunc func(tea.Msg) (tea.Model, tea.Cmd)
@@ -53,6 +64,7 @@ type RacerModel struct {
 	currentViewFunc teaViewFunc
 
 	game *Game
+	quoteDb *QuoteDb
 	settings *GameSettings
 	config *Config2
 	stats *GameStats
@@ -60,8 +72,12 @@ type RacerModel struct {
 	wordDb *WordDb
 	selectedWordList *WordList
 
-	allStats table.Model
+	history *TestHistoryModel
 	allStatsErr error
+	bests table.Model
+	statsView statsView
+	dashboard *DashboardModel
+	keyboard *KeyboardModel
 
 	fileSaver chan any
 	close chan struct{}
@@ -72,32 +88,34 @@ type RacerModel struct {
 	playerInfoModel *PlayerInfoModel
 
 	introModel *IntroModel
+	replay *ReplayModel
 
-	db *sql.DB
-	insertTestStmt *sql.Stmt
-	getAllTestsStmt *sql.Stmt
-}
+	ghost *RacerTest
+	ghostTestId int
 
-type saveGameStatsRequest struct {
-	stats *GameStats
-}
+	challenge *Challenge
+	challengeInput *ChallengeInputModel
 
-type saveGameTestRequest struct {
-	test *RacerTest
+	store Store
+	// keyStats weighs the words of adaptive tests.
+	keyStats *engine.KeyStats
+
+	// reviewing is set while the tests are made of the words in reviewDue.
+	reviewing bool
+	reviewDue []*ReviewWord
+	reviewSummary *ReviewSummary
+	menuMsg string
 }
 
-type saveGameStatsAndTestRequest struct {
+type saveGameStatsRequest struct {
 	stats *GameStats
-	test *RacerTest
 }
 
 type savePlayerInfoRequest struct {
 	name string
 }
 
-func readIntroText() ([]string, error) {
-	path := os.ExpandEnv("$HOME/.go-racer/wuxia/intro.txt")
-
+func readIntroText(path string) ([]string, error) {
 	file, err := os.Open(path)
 
 	if err != nil {
@@ -136,7 +154,9 @@ func readIntroText() ([]string, error) {
 	return chunks, nil
 }
 
-func NewRacerModel() (*RacerModel, error) {
+// NewRacerModel loads the config, word lists and database. Locations set
+// in paths override the ones in the config file.
+func NewRacerModel(paths Paths) (*RacerModel, error) {
 	model := &RacerModel{
 		clock: clock.New(),
 		stateUpdateFunc: make(map[RacerState]teaUpdateFunc),
@@ -148,13 +168,13 @@ func NewRacerModel() (*RacerModel, error) {
 
 	go model.listen()
 
-	options := []string{ "start", "begin", "settings", "stats", "quit" }
+	options := []string{ "start", "review", "begin", "enter challenge code", "settings", "stats", "quit" }
 	menu := &List{}
 	menu.SetItems(options)
 
 	model.menu = menu
 
-	config, err := ReadOrCreateConfig()
+	config, err := ReadOrCreateConfig(paths)
 
 	if err != nil {
 		return nil, err
@@ -162,8 +182,7 @@ func NewRacerModel() (*RacerModel, error) {
 
 	model.config = config
 
-	path := config.data
-	path = os.ExpandEnv(path)
+	path := config.paths.WordsDir
 
 	_, err = os.Lstat(path)
 
@@ -171,7 +190,7 @@ func NewRacerModel() (*RacerModel, error) {
 		return nil, fmt.Errorf("invalid data path %s", path)
 	}
 
-	chunks, err := readIntroText()
+	chunks, err := readIntroText(config.paths.IntroFile())
 
 	if err != nil {
 		return nil, err
@@ -188,47 +207,34 @@ func NewRacerModel() (*RacerModel, error) {
 	model.wordDb = wordDb
 	model.selectedWordList = wordDb.wordLists[config.TestName]
 
-	stats, err := ReadGameStats()
+	quoteDb, err := LoadQuoteDb(config.paths.QuotesDir())
 
 	if err != nil {
 		return nil, err
 	}
 
-	model.stats = stats
-
-	db, err := SetupDB(defaultDbPath)
+	model.quoteDb = quoteDb
 
-	if err != nil {
-		return nil, err
-	}
-	model.db = db
-
-	_, err = GetGameStats(db)
+	store, err := OpenDuckDBStore(config.paths.DbFile)
 
 	if err != nil {
 		return nil, err
 	}
+	model.store = store
 
-	playerInfo, found, err := GetPlayerInfo(db)
+	stats, err := store.GetGameStats()
 
 	if err != nil {
 		return nil, err
 	}
 
-	insertTestStmt, err := prepareStatement(insertTestStmtStr, db)
-
-	if err != nil {
-		return nil, err
-	}
-
-	model.insertTestStmt = insertTestStmt
+	model.stats = stats
 
-	getAllTestsQueryStmt, err := prepareStatement(getAllTestsQueryStr, db)
+	playerInfo, found, err := store.GetPlayerInfo()
 
 	if err != nil {
 		return nil, err
 	}
-	model.getAllTestsStmt = getAllTestsQueryStmt
 
 	model.playerInfo = playerInfo
 	model.playerFound = found
@@ -243,7 +249,7 @@ func NewRacerModel() (*RacerModel, error) {
 	game.racer = model
 	model.game = game
 
-	optionNames := []string{ "words", "mode", "time", "words test size", "allow backspace" }
+	optionNames := []string{ "words", "mode", "time", "words test size", "quote length", "allow backspace", "punctuation", "numbers", "ghost", "adaptive", "sampling", "lazy mode", "stop on error" }
 
 	wordBank := make([]string, 0, len(wordDb.wordLists))
 
@@ -254,12 +260,14 @@ func NewRacerModel() (*RacerModel, error) {
 	slices.Sort(wordBank)
 
 	times := []string{"15", "25",  "30", "60", "120" }
-	backspaceOptions := []string{ "yes", "no" }
+	yesNoOptions := []string{ "yes", "no" }
 
-	modeOptions := []string{ "time", "words" }
+	modeOptions := []string{ "time", "words", "quote" }
 	wordsTestSize := []string{ "25", "50", "100" }
+	quoteLengths := []string{ "all", "short", "medium", "long" }
+	ghostOptions := []string{ "off", "pb" }
 
-	settingOptions := [][]string{ wordBank, modeOptions, times, wordsTestSize, backspaceOptions }
+	settingOptions := [][]string{ wordBank, modeOptions, times, wordsTestSize, quoteLengths, yesNoOptions, yesNoOptions, yesNoOptions, ghostOptions, adaptiveOptions, samplingOptions, yesNoOptions, stopOnErrorOptions }
 
 	settings := NewGameSettings(optionNames, settingOptions)
 
@@ -267,25 +275,30 @@ func NewRacerModel() (*RacerModel, error) {
 	model.settings = settings
 	settings.model = model
 
-	model.allStats = table.New()
+	model.history = NewTestHistoryModel(config, wordBank)
+	model.keyboard = NewKeyboardModel(config, wordBank)
 
-	tableCols := []table.Column{
-		{ Title: "Id", Width: 10 },
-		{ Title: "Name", Width: 10 },
-		{ Title: "Test Duration", Width: 10 },
-		{ Title: "Mode", Width: 10 },
+	model.bests = table.New()
+	model.dashboard = &DashboardModel{ testNames: wordBank }
+
+	bestsCols := []table.Column{
+		{ Title: "Name", Width: 12 },
+		{ Title: "Mode", Width: 6 },
+		{ Title: "Size", Width: 6 },
 		{ Title: "Allow Backspace", Width: 10 },
-		{ Title: "Test Size", Width: 10 },
+		{ Title: "Punctuation", Width: 10 },
+		{ Title: "Numbers", Width: 10 },
+		{ Title: "Sampling", Width: 14 },
+		{ Title: "Stop On Error", Width: 8 },
+		{ Title: "Adaptive", Width: 8 },
+		{ Title: "Wpm", Width: 6 },
 		{ Title: "Accuracy", Width: 10 },
-		{ Title: "Words", Width: 10 },
-		{ Title: "Input", Width: 10 },
-		{ Title: "Wpm", Width: 10 },
-		{ Title: "Cps", Width: 10 },
-		{ Title: "Rle", Width: 10 },
-		{ Title: "Raw Input", Width: 10 },
+		{ Title: "Test Id", Width: 8 },
+		{ Title: "Date", Width: 12 },
+		{ Title: "Attempts", Width: 8 },
 	}
 
-	model.allStats.SetColumns(tableCols)
+	model.bests.SetColumns(bestsCols)
 
 	model.registerStateUpdateFunc(MAIN_MENU, model.updateMainMenu)
 	model.registerStateViewFunc(MAIN_MENU, model.viewMainMenu)
@@ -305,6 +318,13 @@ func NewRacerModel() (*RacerModel, error) {
 	model.registerStateUpdateFunc(PLAYER_INFO, model.updatePlayerInfoModel)
 	model.registerStateViewFunc(PLAYER_INFO, model.playerInfoModel.render)
 
+	model.registerStateUpdateFunc(REPLAY, model.updateReplay)
+	model.registerStateViewFunc(REPLAY, model.viewReplay)
+
+	model.challengeInput = &ChallengeInputModel{}
+	model.registerStateUpdateFunc(CHALLENGE, model.updateChallengeInput)
+	model.registerStateViewFunc(CHALLENGE, model.challengeInput.render)
+
 	model.SetState(MAIN_MENU)
 
 	return model, nil
@@ -328,8 +348,6 @@ func (r *RacerModel) listen() {
 			return
 		case req := <-r.fileSaver:
 			switch rq :=req.(type) {
-			case saveGameStatsAndTestRequest:
-				r.saveGameStatsAndTest(rq)
 			case saveGameStatsRequest:
 				r.saveGameStats(rq)
 			case savePlayerInfoRequest:
@@ -344,7 +362,7 @@ func (r *RacerModel) insertPlayerInfo(rq savePlayerInfoRequest) {
 		name: rq.name,
 	}
 
-	err := InsertPlayerInfo(r.db, params)
+	err := r.store.InsertPlayerInfo(params)
 
 	if err != nil {
 		r.errCh <- err
@@ -354,40 +372,32 @@ func (r *RacerModel) insertPlayerInfo(rq savePlayerInfoRequest) {
 	panic("here")
 }
 
-func (r *RacerModel) saveGameStatsAndTest(rq saveGameStatsAndTestRequest) {
-	tx, err := r.db.Begin()
-
-	if err != nil {
-		tx.Rollback()
-		r.errCh <- err
-	}
-
-	if err := UpdateGameStatsTx(tx, rq.stats); err != nil {
-		tx.Rollback()
-		r.errCh <- err
-	}
-
-	if err := InsertRacerTestTx(tx, rq.test); err != nil {
-		tx.Rollback()
-		r.errCh <- err
-	}
-
-	if err := tx.Commit(); err != nil {
-		tx.Rollback()
-		r.errCh <- err
-	}
-}
-
 type saveFileErr error
 type insertRacerTestErr error
 
-func (r *RacerModel) insertRacerTestCmd(test *RacerTest) tea.Cmd {
+type insertRacerTestSuccess struct {
+	gameId int
+	prevBest *PersonalBest
+}
+
+// insertRacerTestCmd looks up the personal best the test is competing
+// against before saving it so the results screen can show the difference.
+func (r *RacerModel) insertRacerTestCmd(gameId int, params *PersonalBestParams, test *RacerTest) tea.Cmd {
 	return func() tea.Msg {
-		if err := InsertRacerTestStmt(r.insertTestStmt, test); err != nil {
+		prevBest, _, err := r.store.GetPersonalBest(params)
+
+		if err != nil {
 			return insertRacerTestErr(err)
 		}
 
-		return nil
+		if err := r.store.InsertTest(test); err != nil {
+			return insertRacerTestErr(err)
+		}
+
+		return insertRacerTestSuccess{
+			gameId: gameId,
+			prevBest: prevBest,
+		}
 	}
 }
 
@@ -403,7 +413,7 @@ func (r *RacerModel) checkErrorCmd() tea.Cmd {
 }
 
 func (r *RacerModel) saveGameStats(rq saveGameStatsRequest) {
-	if err := UpdateGameStats(r.db, rq.stats); err != nil {
+	if err := r.store.UpdateGameStats(rq.stats); err != nil {
 		r.errCh <- err
 	}
 }
@@ -438,11 +448,7 @@ func (r *RacerModel) SetState(state RacerState) {
 }
 
 func (r *RacerModel) Init() tea.Cmd {
-	return tea.Batch(r.ProcessTestsCmd(), r.clock.Init())
-}
-
-type UpdateWordDb struct {
-	l *WordList
+	return tea.Batch(r.getReviewQueueCmd(), r.getAdaptiveStatsCmd(), r.clock.Init())
 }
 
 func (r *RacerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -457,9 +463,7 @@ func (r *RacerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return r, r.Shutdown()
 		}
 	case RacerModelShutdownMsg:
-		r.insertTestStmt.Close()
-		r.getAllTestsStmt.Close()
-		r.db.Close()
+		r.store.Close()
 		return r, tea.Quit
 
 	case insertRacerTestErr:
@@ -467,11 +471,26 @@ func (r *RacerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return r, pcmd
 	//case saveFileErr:
 	//	pcmd = tea.Printf("%v\n", msg)
-	case UpdateWordDb:
-		r.wordDb.wordLists[msg.l.Name] = msg.l
-		r.settings.appendSettingsOption("words", msg.l.Name)
+	case ghostLoadedMsg:
+		r.ghost = msg.test
+	case loadGhostErr:
+		r.ghost = nil
+		pcmd = tea.Printf("%v\n", msg)
+		return r, pcmd
+	case adaptiveStatsMsg:
+		r.keyStats = msg.stats
+	case adaptiveStatsErr:
+		pcmd = tea.Printf("%v\n", msg)
+		return r, pcmd
+	case reviewQueueMsg:
+		r.reviewDue = msg.due
+		r.reviewSummary = msg.summary
+	case reviewQueueErr:
+		pcmd = tea.Printf("%v\n", msg)
+		return r, pcmd
 	case tea.WindowSizeMsg:
 		r.width, r.height = msg.Width, msg.Height
+		r.game.setWidth(msg.Width)
 	}
 
 	r.clock, cmd = r.clock.Update(msg)
@@ -507,6 +526,8 @@ func (r *RacerModel) updateMainMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
 	menu := r.menu
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		r.menuMsg = ""
+
 		switch msg.String() {
 		case "esc":
 			return r, r.Shutdown()
@@ -520,15 +541,28 @@ func (r *RacerModel) updateMainMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch selectedOption {
 			case "start":
 				r.SetState(GAME)
+				return r, r.loadGhostCmd()
+			case "review":
+				if len(r.reviewDue) == 0 {
+					r.menuMsg = "no words are due for review"
+					return r, nil
+				}
+
+				r.reviewing = true
+				r.SetState(GAME)
 			case "begin":
 				r.SetState(GAME_INTRO)
 				return r, doChunkTick2(string(r.introModel.lines[0]), r.introModel.idx)
+			case "enter challenge code":
+				r.challengeInput.input = nil
+				r.challengeInput.err = nil
+				r.SetState(CHALLENGE)
 			case "settings":
 				r.SetState(SETTINGS)
 			case "stats":
 				r.SetState(STATISTICS)
-				r.allStats.Focus()
-				return r, r.getAllTests()
+				r.statsView = statsDashboard
+				return r, tea.Batch(r.getDashboardCmd(), r.getTestsCmd(), r.getPersonalBestsCmd(), r.getKeyStatsCmd())
 			case "quit":
 				return r, r.Shutdown()
 			}
@@ -542,12 +576,25 @@ func (r *RacerModel) viewMainMenu() string {
 	builder := &strings.Builder{}
 
 	for idx, item := range menu.items {
+		if item == "review" && r.reviewSummary != nil {
+			item = fmt.Sprintf("review (%d due)", r.reviewSummary.Due)
+		}
+
 		if idx == menu.cursor {
 			builder.WriteString(cursorStyle.Render(item)+"\n")
 		} else {
 			builder.WriteString(item+"\n")
 		}
 	}
+
+	if s := r.reviewSummary; s != nil && s.Words > 0 {
+		fmt.Fprintf(builder, "\nreview queue: %d due, %d reviewed today, %d words\n", s.Due, s.ReviewedToday, s.Words)
+	}
+
+	if r.menuMsg != "" {
+		fmt.Fprintf(builder, "\n%s\n", r.menuMsg)
+	}
+
 	return builder.String()
 }
 
@@ -567,67 +614,46 @@ func (r *RacerModel) updateGame(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEsc:
-			g.Reset()
-			r.SetState(MAIN_MENU)
+			r.exitGame()
 			return r, nil
 		case tea.KeyRunes:
-			g.appendByte(byte(msg.Runes[0]))
-			if len(g.target) == len(g.inputs) {
-				g.finished = true
-				cmd = g.stopGame(g.id)
+			// input methods and pastes can deliver several runes at once
+			for _, char := range msg.Runes {
+				if g.appendRune(char, g.elapsed()) {
+					g.finish()
+					break
+				}
 			}
 		case tea.KeyBackspace:
-			if !g.allowBackspace {
-				break
-			}
-			if len(g.inputs) == 0 {
-				break
-			}
-			g.trimByte()
+			g.trimRune(g.elapsed())
 		case tea.KeySpace:
-			g.appendByte(' ')
-			if len(g.target) == len(g.inputs) {
-				g.finished = true
-				cmd = g.stopGame(g.id)
+			if g.appendRune(' ', g.elapsed()) {
+				g.finish()
 			}
 		case tea.KeyTab:
 			g.restart()
 			cmd = g.startGame(g.id)
 			return r, cmd
 		}
-	case timer.TickMsg:
-		if msg.Timeout {
-			g.finished = true
+	case ghostTickMsg:
+		if msg.gameId != g.id || g.ghost == nil {
 			break
 		}
 
-		if g.started && !g.finished && g.mode == "time" {
-			g.sample()
-		}
+		g.ghost.advance(g.elapsed())
+		return r, g.ghostTickCmd(g.id)
 	case GameTickMsg:
-		if g.mode != "words" {
-			break
-		}
-
 		if msg.gameId != g.id {
 			break
 		}
 
-		if !g.finished && msg.Timeout {
-			g.finished = true
+		// a timed test ends on the first tick after its duration
+		if g.advance(g.elapsed()) {
+			g.finish()
 			break
 		}
 
-		if g.started && !g.finished {
-			g.sample()
-			return r, g.tickCmd(false, g.id)
-		}
-	}
-
-	var timerCmd tea.Cmd
-
-	if g.started && !g.finished && g.mode == "time" {
-		g.timer, timerCmd = g.timer.Update(msg)
+		return r, g.tickCmd(g.id)
 	}
 
 	if g.finished {
@@ -644,42 +670,67 @@ func (r *RacerModel) updateGame(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	   	g.missedWords = strings.Join(words, " ")
 
+		s := g.session
+		samples := s.Samples()
+		accs := make([]float64, 0, len(samples))
+		cps := make([]int, 0, len(samples))
+		wpms := make([]int, 0, len(samples))
+
+		for _, sample := range samples {
+			accs = append(accs, sample.Accuracy)
+			cps = append(cps, sample.Cps)
+			wpms = append(wpms, sample.Wpm)
+		}
+
 		test := &RacerTest{
-			Accuracy: g.accuracy*100,
-			Target: g.target,
-			Input: string(g.inputs),
+			Accuracy: s.Accuracy()*100,
+			Target: string(s.Target()),
+			Input: string(s.Input()),
 			Test: g.testName,
 			Time: g.testDuration,
 			Mode: g.mode,
 			TestSize: g.wordsTestSize,
+			QuoteId: g.quoteId(),
+			QuoteLength: g.quoteLength(),
+			Seed: g.seed,
 			AllowBackspace: g.allowBackspace,
-			Cps: computeCps(g.charsPerSec),
-			Wpm: 0,
-			Rle: g.alignment.rle(),
-			RawInput: g.alignment.rawString(),
+			Punctuation: g.punctuation,
+			Numbers: g.numbers,
+			Sampling: g.sampling,
+			SamplingSize: g.samplingSize,
+			Lazy: g.lazy,
+			StopOnError: g.stopOnError,
+			Adaptive: g.adaptive,
+			Cps: s.Cps(),
+			Wpm: s.Wpm(),
+			RawWpm: s.RawWpm(),
+			Rle: s.Alignment().Rle(),
+			RawInput: s.Alignment().RawString(),
 			SampleRate: 1,
-			//AccList: slices.Clone(g.accs),
-			//CpsList: slices.Clone(g.charsPerSec),
-			//WpmList: slices.Repeat([]int{0}, len(g.charsPerSec)), 
-		}
-
-		//stats := r.stats.Copy()
-
-		//req := saveGameStatsAndTestRequest{
-		//	stats: stats,
-		//	test: test,
-		//}
-
-		var cmd tea.Cmd
-
-		if g.id % 3 == 0 {
-			cmd = r.ProcessTestsCmd()
+			AccList: accs,
+			CpsList: cps,
+			WpmList: wpms,
+			Keystrokes: slices.Clone(s.Keystrokes()),
 		}
 
-		return r, tea.Batch(cmd, timerCmd, r.insertRacerTestCmd(test), cmd)
+		return r, tea.Sequence(
+			r.insertRacerTestCmd(g.id, g.bestParams(), test),
+			r.loadGhostCmd(),
+			r.getAdaptiveStatsCmd(),
+			r.reviewTestCmd(test.Target, test.Keystrokes),
+		)
 	}
 
-	return r, tea.Batch(cmd, timerCmd)
+	return r, cmd
+}
+
+func (r *RacerModel) exitGame() {
+	r.game.Reset()
+	r.ghostTestId = 0
+	r.ghost = nil
+	r.challenge = nil
+	r.reviewing = false
+	r.SetState(MAIN_MENU)
 }
 
 func (r *RacerModel) updateGameNotStarted(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -690,8 +741,7 @@ func (r *RacerModel) updateGameNotStarted(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
-			g.Reset()
-			r.SetState(MAIN_MENU)
+			r.exitGame()
 			return r, nil
 		case "enter":
 			g.started = true
@@ -702,31 +752,36 @@ func (r *RacerModel) updateGameNotStarted(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	var timerCmd tea.Cmd
-
-	if g.started && !g.finished && g.mode == "time" {
-		g.timer, timerCmd = g.timer.Update(msg)
-	}
 	stats := r.stats.Copy()
 	req := saveGameStatsRequest{ stats }
-	return r, tea.Batch(cmd, timerCmd, r.sendSaveRequest(req))
+	return r, tea.Batch(cmd, r.sendSaveRequest(req))
 }
 
 func (r *RacerModel) updateGameFinished(msg tea.Msg) (tea.Model, tea.Cmd) {
 	g := r.game
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case insertRacerTestSuccess:
+		if msg.gameId == g.id {
+			g.prevBest = msg.prevBest
+			g.bestLoaded = true
+		}
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
-			g.Reset()
-			r.SetState(MAIN_MENU)
+			r.exitGame()
 			return r, nil
 		case "r":
 			g.Reset()
 			r.SetState(GAME)
 			return r, nil
 		case "enter":
+			if r.reviewing && len(r.reviewDue) == 0 {
+				r.exitGame()
+				r.menuMsg = "every due word was reviewed"
+				return r, nil
+			}
+
 			g.Reset()
 			r.SetState(GAME)
 			g.started = true
@@ -737,17 +792,15 @@ func (r *RacerModel) updateGameFinished(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	var timerCmd tea.Cmd
 	var saveCmd tea.Cmd
 
 	if g.started && !g.finished && g.mode == "time" {
-		g.timer, timerCmd = g.timer.Update(msg)
 		stats := r.stats.Copy()
 		req := saveGameStatsRequest{ stats }
 		saveCmd = r.sendSaveRequest(req)
 	}
 
-	return r, tea.Batch(cmd, timerCmd, saveCmd)
+	return r, tea.Batch(cmd, saveCmd)
 }
 
 func (r *RacerModel) updateGameSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -777,13 +830,8 @@ func (r *RacerModel) updateGameSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return r, settings.SaveSettings
 			}
 
-			switch value {
-			case "time":
-				settings.HideSettingsOption("words test size")
-				settings.UnhideSettingsOption("time")
-			case "words":
-				settings.HideSettingsOption("time")
-				settings.UnhideSettingsOption("words test size")
+			if optionName == "mode" {
+				settings.showModeOptions(value)
 			}
 
 			if settings.showSave {
@@ -815,88 +863,422 @@ func (r *RacerModel) updateGameSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return r, nil
 }
 
-type getAllTestsErr error
-type getAllTestsSuccess struct {
-	tests []*RacerTest
+type getTestsErr error
+type getTestsSuccess struct {
+	page *TestPage
 }
 
-func (r *RacerModel) getAllTests() tea.Cmd {
+func (r *RacerModel) getTestsCmd() tea.Cmd {
+	query := r.history.query
+
 	return func() tea.Msg {
-		tests, err := GetAllTests(r.getAllTestsStmt)
+		page, err := r.store.GetTests(&query)
 
 		if err != nil {
-			return getAllTestsErr(err)
+			return getTestsErr(err)
 		}
 
-		return getAllTestsSuccess{
-			tests: tests,
+		return getTestsSuccess{
+			page: page,
 		}
 	}
 }
 
-func (t *RacerTest) row() []string {
-	return []string{
-		fmt.Sprintf("%d", t.Id),
-		t.Test,
-		fmt.Sprintf("%d", t.Time),
-		t.Mode,
-		fmt.Sprintf("%v", t.AllowBackspace),
-		fmt.Sprintf("%d", t.TestSize),
-		fmt.Sprintf("%.2f", t.Accuracy),
-		t.Target,
-		t.Input,
-		fmt.Sprintf("%d", t.Wpm),
-		fmt.Sprintf("%d", t.Cps),
-		t.Rle,
-		fmt.Sprintf("%s", t.RawInput),
-	}
+type getTestDetailErr error
+type getTestDetailSuccess struct {
+	test *RacerTest
 }
 
-func convertTestsToRows(tests []*RacerTest) []table.Row {
-	rows := make([]table.Row, 0, len(tests))
+func (r *RacerModel) getTestDetailCmd(id int) tea.Cmd {
+	return func() tea.Msg {
+		test, err := r.store.GetTest(id)
+
+		if err != nil {
+			return getTestDetailErr(err)
+		}
 
-	for _, test := range tests {
-		rows = append(rows, test.row())
+		return getTestDetailSuccess{
+			test: test,
+		}
 	}
-	return rows
 }
 
 func (r *RacerModel) updateStats(msg tea.Msg) (tea.Model, tea.Cmd) {
+	history := r.history
+
 	switch msg := msg.(type) {
+	case getPersonalBestsErr:
+		r.allStatsErr = msg
+		return r, nil
+	case getPersonalBestsSuccess:
+		r.bests.SetRows(convertBestsToRows(msg.bests))
+		return r, nil
+	case getDashboardErr:
+		r.dashboard.err = msg
+		return r, nil
+	case getDashboardSuccess:
+		r.dashboard.err = nil
+		r.dashboard.dashboard = msg.dashboard
+		return r, nil
+	case getKeyStatsErr:
+		r.keyboard.err = msg
+		return r, nil
+	case getKeyStatsSuccess:
+		r.keyboard.stats = msg.stats
+		return r, nil
 	case tea.KeyMsg:
+		if msg.String() == "tab" && history.detail == nil {
+			history.table.Blur()
+			r.bests.Blur()
+			r.statsView = (r.statsView + 1) % numStatsViews
+
+			switch r.statsView {
+			case statsAllTests:
+				history.table.Focus()
+			case statsBests:
+				r.bests.Focus()
+			}
+			return r, nil
+		}
+
+		switch r.statsView {
+		case statsDashboard:
+			return r.updateDashboard(msg)
+		case statsBests:
+			return r.updateBests(msg)
+		case statsKeys:
+			return r.updateKeyboard(msg)
+		}
+
+		if history.detail != nil {
+			if msg.String() == "esc" || msg.String() == "enter" {
+				history.detail = nil
+			}
+			return r, nil
+		}
+
 		switch msg.String() {
 		case "j":
-			r.allStats.MoveDown(1)
+			history.table.MoveDown(1)
+			return r, nil
 		case "k":
-			r.allStats.MoveUp(1)
+			history.table.MoveUp(1)
+			return r, nil
+		case "l", "right":
+			if history.nextPage() {
+				return r, r.getTestsCmd()
+			}
+			return r, nil
+		case "h", "left":
+			if history.prevPage() {
+				return r, r.getTestsCmd()
+			}
+			return r, nil
+		case "s":
+			history.nextSortColumn()
+			return r, r.getTestsCmd()
+		case "r":
+			history.reverseSort()
+			return r, r.getTestsCmd()
+		case "w":
+			history.nextTestName()
+			return r, r.getTestsCmd()
+		case "m":
+			history.nextMode()
+			return r, r.getTestsCmd()
+		case "b":
+			history.nextBackspace()
+			return r, r.getTestsCmd()
+		case "d":
+			history.nextDateRange(time.Now())
+			return r, r.getTestsCmd()
+		case "enter":
+			if id, ok := history.selectedId(); ok {
+				return r, r.getTestDetailCmd(id)
+			}
+			return r, nil
+		case "p":
+			if id, ok := history.selectedId(); ok {
+				return r, r.loadReplayCmd(id)
+			}
+			return r, nil
+		case "g":
+			id, ok := history.selectedId()
+
+			if !ok {
+				return r, nil
+			}
+
+			history.table.Blur()
+			r.ghostTestId = id
+			r.game.Reset()
+			r.SetState(GAME)
+			return r, r.loadGhostCmd()
 		case "esc":
-			r.allStats.Blur()
+			history.table.Blur()
 			r.SetState(MAIN_MENU)
 			return r, nil
 		}
-	case getAllTestsErr:
+	case loadReplayErr:
+		r.allStatsErr = msg
+		return r, nil
+	case loadReplaySuccess:
+		replay, err := NewReplayModel(r.config, r.quoteDb, msg.test)
+
+		if err != nil {
+			r.allStatsErr = err
+			return r, nil
+		}
+
+		r.allStatsErr = nil
+		replay.game.setWidth(r.width)
+		r.replay = replay
+		r.SetState(REPLAY)
+		return r, replay.Init()
+	case getTestDetailErr:
+		r.allStatsErr = msg
+		return r, nil
+	case getTestDetailSuccess:
+		r.allStatsErr = nil
+		history.detail = msg.test
+		return r, nil
+	case getTestsErr:
 		r.allStatsErr = msg
-	case getAllTestsSuccess:
+	case getTestsSuccess:
 		r.allStatsErr = nil
-		tests := msg.tests
-		rows := convertTestsToRows(tests)
-		r.allStats.SetRows(rows)
-		r.allStats.Focus()
+		history.setPage(msg.page)
 	}
 
-	_, cmd := r.allStats.Update(msg)
+	var cmd tea.Cmd
+	history.table, cmd = history.table.Update(msg)
 
 	return r, cmd
 }
 
+func (r *RacerModel) updateBests(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "j":
+		r.bests.MoveDown(1)
+	case "k":
+		r.bests.MoveUp(1)
+	case "esc":
+		r.bests.Blur()
+		r.SetState(MAIN_MENU)
+	}
+
+	return r, nil
+}
+
+func (r *RacerModel) updateDashboard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "w":
+		r.dashboard.nextTestName()
+		return r, r.getDashboardCmd()
+	case "m":
+		r.dashboard.nextMode()
+		return r, r.getDashboardCmd()
+	case "esc":
+		r.SetState(MAIN_MENU)
+	}
+
+	return r, nil
+}
+
+func (r *RacerModel) updateKeyboard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "w":
+		r.keyboard.nextTestName()
+		return r, r.getKeyStatsCmd()
+	case "m":
+		r.keyboard.nextMode()
+		return r, r.getKeyStatsCmd()
+	case "c":
+		r.keyboard.nextMetric()
+	case "esc":
+		r.SetState(MAIN_MENU)
+	}
+
+	return r, nil
+}
+
+type getKeyStatsErr error
+type getKeyStatsSuccess struct {
+	stats *engine.KeyStats
+}
+
+func (r *RacerModel) getKeyStatsCmd() tea.Cmd {
+	filter := r.keyboard.filter
+
+	return func() tea.Msg {
+		stats, err := r.store.GetKeyStats(&filter)
+
+		if err != nil {
+			return getKeyStatsErr(err)
+		}
+
+		return getKeyStatsSuccess{
+			stats: stats,
+		}
+	}
+}
+
+type getDashboardErr error
+type getDashboardSuccess struct {
+	dashboard *Dashboard
+}
+
+func (r *RacerModel) getDashboardCmd() tea.Cmd {
+	filter := r.dashboard.filter
+
+	return func() tea.Msg {
+		dashboard, err := r.store.GetDashboard(&filter)
+
+		if err != nil {
+			return getDashboardErr(err)
+		}
+
+		return getDashboardSuccess{
+			dashboard: dashboard,
+		}
+	}
+}
+
 func (r *RacerModel) viewStats() string {
 	builder := &strings.Builder{}
-	builder.WriteString(r.allStats.View())
+
+	switch r.statsView {
+	case statsDashboard:
+		builder.WriteString(r.dashboard.render(r.width))
+	case statsAllTests:
+		builder.WriteString(r.history.render(r.width))
+	case statsBests:
+		builder.WriteString("personal bests\n")
+		builder.WriteString(r.bests.View())
+	case statsKeys:
+		builder.WriteString(r.keyboard.render())
+	}
 	builder.WriteRune('\n')
+
+	if r.allStatsErr != nil {
+		fmt.Fprintf(builder, "error: %v\n", r.allStatsErr)
+	}
+
+	switch r.statsView {
+	case statsDashboard:
+		builder.WriteString("press w to filter by word list and m to filter by mode\n")
+	case statsAllTests:
+		if r.history.detail != nil {
+			builder.WriteString("press enter or esc to go back to all tests\n")
+			return builder.String()
+		}
+
+		builder.WriteString("press enter to open the selected test\n")
+		builder.WriteString("press p to replay the selected test\n")
+		builder.WriteString("press g to race against the selected test\n")
+		builder.WriteString("press h and l to change the page\n")
+		builder.WriteString("press s to change the sort column and r to reverse it\n")
+		builder.WriteString("press w, m, b and d to filter by word list, mode, backspace and date\n")
+	case statsKeys:
+		builder.WriteString("press w to filter by word list and m to filter by mode\n")
+		builder.WriteString("press c to colour the keys by error rate or latency\n")
+	}
+	builder.WriteString("press tab to switch between the dashboard, all tests, personal bests and keys\n")
 	builder.WriteString("press esc to go back to main menu\n")
 	return builder.String()
 }
 
+type getPersonalBestsErr error
+type getPersonalBestsSuccess struct {
+	bests []*PersonalBest
+}
+
+func (r *RacerModel) getPersonalBestsCmd() tea.Cmd {
+	return func() tea.Msg {
+		bests, err := r.store.GetPersonalBests()
+
+		if err != nil {
+			return getPersonalBestsErr(err)
+		}
+
+		return getPersonalBestsSuccess{
+			bests: bests,
+		}
+	}
+}
+
+// size is the quote length of quote tests, every quote has a size of 0.
+func (b *PersonalBest) size() string {
+	if b.Mode == "quote" && b.QuoteLength != "" {
+		return b.QuoteLength
+	}
+
+	return fmt.Sprintf("%d", b.Size)
+}
+
+func (b *PersonalBest) row() []string {
+	return []string{
+		b.TestName,
+		b.Mode,
+		b.size(),
+		fmt.Sprintf("%v", b.AllowBackspace),
+		fmt.Sprintf("%v", b.Punctuation),
+		fmt.Sprintf("%v", b.Numbers),
+		samplingLabel(b.Sampling, b.SamplingSize, b.Lazy),
+		b.StopOnError,
+		b.Adaptive,
+		fmt.Sprintf("%d", b.Wpm),
+		fmt.Sprintf("%.2f", b.Accuracy),
+		fmt.Sprintf("%d", b.TestId),
+		b.CreatedAt.Format(time.DateOnly),
+		fmt.Sprintf("%d", b.Attempts),
+	}
+}
+
+func convertBestsToRows(bests []*PersonalBest) []table.Row {
+	rows := make([]table.Row, 0, len(bests))
+
+	for _, best := range bests {
+		rows = append(rows, best.row())
+	}
+	return rows
+}
+
+type loadReplayErr error
+type loadReplaySuccess struct {
+	test *RacerTest
+}
+
+func (r *RacerModel) loadReplayCmd(id int) tea.Cmd {
+	return func() tea.Msg {
+		test, err := r.store.GetTest(id)
+
+		if err != nil {
+			return loadReplayErr(err)
+		}
+
+		return loadReplaySuccess{
+			test: test,
+		}
+	}
+}
+
+func (r *RacerModel) updateReplay(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case replayExitMsg:
+		r.replay = nil
+		r.SetState(STATISTICS)
+		return r, nil
+	}
+
+	_, cmd := r.replay.Update(msg)
+
+	return r, cmd
+}
+
+func (r *RacerModel) viewReplay() string {
+	return r.replay.View()
+}
+
 type chunkTickMsg struct{}
 
 func doChunkTick() tea.Cmd {
@@ -962,13 +1344,14 @@ func (r *RacerModel) updatePlayerInfoModel(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEsc:
-			info.input = []byte{}
+			info.input = []rune{}
 			r.SetState(MAIN_MENU)
 			return r, nil
 		case tea.KeyRunes:
-			char := byte(msg.Runes[0])
-			if isValidChar(char) {
-				info.input = append(info.input, char)
+			for _, char := range msg.Runes {
+				if isValidChar(char) {
+					info.input = append(info.input, char)
+				}
 			}
 		case tea.KeyBackspace:
 			if len(info.input) != 0 {
@@ -998,12 +1381,36 @@ func (r *RacerModel) updatePlayerInfoModel(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return r, nil
 }
 
+func (r *RacerModel) updateChallengeInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m := r.challengeInput
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.input = nil
+			m.err = nil
+			r.SetState(MAIN_MENU)
+		case tea.KeyRunes:
+			m.input = append(m.input, msg.Runes...)
+		case tea.KeyBackspace:
+			if len(m.input) != 0 {
+				m.input = m.input[:len(m.input)-1]
+			}
+		case tea.KeyEnter:
+			m.err = r.SetChallenge(string(m.input))
+		}
+	}
+
+	return r, nil
+}
+
 type insertPlayerInfoErr error
 type insertPlayerSuccess struct{}
 
 func (r *RacerModel) insertPlayerInfoCmd(params *PlayerInfoInsertParams) tea.Cmd {
 	return func() tea.Msg {
-		if err := InsertPlayerInfo(r.db, params); err != nil {
+		if err := r.store.InsertPlayerInfo(params); err != nil {
 			return insertPlayerInfoErr(err)
 		}
 
@@ -1011,8 +1418,6 @@ func (r *RacerModel) insertPlayerInfoCmd(params *PlayerInfoInsertParams) tea.Cmd
 	}
 }
 
-type processTestsErr error
-
 type wordPair struct {
 	word string
 	count int
@@ -1051,63 +1456,3 @@ func createWordPairs(wordCount map[string]int) []wordPair {
 //}
 
 type RefreshModel struct{}
-
-func (r *RacerModel) ProcessTestsCmd() tea.Cmd {
-	return func() tea.Msg {
-		tests, err := GetAllTests(r.getAllTestsStmt)
-
-		if err != nil {
-			return processTestsErr(err)
-		}
-
-		wordCount := make(map[string]int)
-
-		for _, test := range tests {
-			input := test.Input
-			target := test.Target[:len(input)]
-			leftIdx := 0
-			for i := 0; i < len(target); i++ {
-				if target[i] != ' ' {
-					continue
-				}
-
-				if string(input[leftIdx:i]) != string(target[leftIdx:i]) {
-					wordCount[string(target[leftIdx:i])]++
-				}
-
-				i++
-				leftIdx = i
-			}
-
-			if string(input[leftIdx:]) != string(target[leftIdx:]) {
-				wordCount[string(target[leftIdx:])]++
-			}
-		}
-
-		pairs := createWordPairs(wordCount)
-
-		if len(pairs) > 50 {
-			pairs = pairs[:50]
-		}
-
-		words := make([]string, 0, len(pairs))
-
-		for _, pair := range pairs {
-			words = append(words, pair.word)
-		}
-
-		wordList := &WordList{
-			Name: "frequent",
-			Words: words,
-		}
-
-		if err := wordList.Save(); err != nil {
-			return processTestsErr(err)
-		}
-
-		return UpdateWordDb{
-			l: wordList,
-		}
-
-	}
-}
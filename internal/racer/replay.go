@@ -0,0 +1,250 @@
+package racer
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	replayTickRate = 50*time.Millisecond
+	minReplaySpeed = 0.25
+	maxReplaySpeed = 4
+)
+
+// ReplayModel plays back a recorded test by feeding its keystrokes into a
+// Game at the pace they were originally typed.
+type ReplayModel struct {
+	game *Game
+	test *RacerTest
+	keyIdx int
+	elapsed time.Duration
+	speed float64
+	paused bool
+	id int
+	quitOnExit bool
+}
+
+type replayTickMsg struct {
+	replayId int
+}
+
+type replayExitMsg struct{}
+
+// NewReplayModel plays back test. The quote of a quote test is looked up
+// in quoteDb, which may be nil.
+func NewReplayModel(config *Config2, quoteDb *QuoteDb, test *RacerTest) (*ReplayModel, error) {
+	if len(test.Keystrokes) == 0 {
+		return nil, fmt.Errorf("no keystrokes recorded for test %d", test.Id)
+	}
+
+	m := &ReplayModel{
+		game: NewGameFromConfig(config),
+		test: test,
+	}
+
+	m.game.quoteDb = quoteDb
+
+	m.reset()
+
+	return m, nil
+}
+
+func (m *ReplayModel) reset() {
+	m.id++
+	m.keyIdx = 0
+	m.elapsed = 0
+	m.speed = 1
+	m.paused = false
+	m.game.Reset()
+	m.game.loadTest(m.test)
+	m.game.started = true
+}
+
+func (m *ReplayModel) tick() tea.Cmd {
+	id := m.id
+	return m.game.clock.Tick(replayTickRate, func(_ time.Time) tea.Msg {
+		return replayTickMsg{ replayId: id }
+	})
+}
+
+// advance moves the replay clock forward and applies every keystroke that
+// was typed before the new position.
+func (m *ReplayModel) advance(d time.Duration) {
+	g := m.game
+	keystrokes := m.test.Keystrokes
+	m.elapsed += d
+
+	for m.keyIdx < len(keystrokes) && keystrokes[m.keyIdx].Offset <= m.elapsed {
+		key := keystrokes[m.keyIdx]
+
+		switch key.Op {
+		case engine.OpDelete:
+			g.trimRune(key.Offset)
+		case engine.OpReject:
+			g.rejectRune(key.Typed, key.Offset)
+		default:
+			g.appendRune(key.Typed, key.Offset)
+		}
+		m.keyIdx++
+	}
+
+	// an untimed test ends with its last keystroke even if the target was
+	// not typed out completely.
+	if m.keyIdx == len(keystrokes) && g.untimed() {
+		g.session.Finish(g.session.Elapsed())
+	}
+
+	g.advance(m.elapsed)
+
+	if g.session.Finished() {
+		m.finish()
+	}
+}
+
+func (m *ReplayModel) finish() {
+	g := m.game
+	g.finish()
+
+	pairs := g.computeMismatchedWords()
+	words := make([]string, 0, len(pairs))
+
+	for _, pair := range pairs {
+		words = append(words, pair.word)
+	}
+
+	g.missedWords = strings.Join(words, " ")
+}
+
+func (m *ReplayModel) exit() tea.Cmd {
+	if m.quitOnExit {
+		return tea.Quit
+	}
+
+	return func() tea.Msg {
+		return replayExitMsg{}
+	}
+}
+
+func (m *ReplayModel) Init() tea.Cmd {
+	return m.tick()
+}
+
+func (m *ReplayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.game.setWidth(msg.Width)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			return m, m.exit()
+		case " ":
+			m.paused = !m.paused
+		case "+":
+			m.speed = min(m.speed*2, maxReplaySpeed)
+		case "-":
+			m.speed = max(m.speed/2, minReplaySpeed)
+		case "r":
+			m.reset()
+			return m, m.tick()
+		}
+	case replayTickMsg:
+		if msg.replayId != m.id || m.game.finished {
+			break
+		}
+
+		if !m.paused {
+			m.advance(time.Duration(float64(replayTickRate)*m.speed))
+		}
+
+		if !m.game.finished {
+			return m, m.tick()
+		}
+	}
+
+	return m, nil
+}
+
+func (m *ReplayModel) View() string {
+	builder := &strings.Builder{}
+
+	state := "playing"
+
+	if m.paused {
+		state = "paused"
+	}
+
+	if m.game.finished {
+		state = "finished"
+	}
+
+	fmt.Fprintf(builder, "replay of test %d (%s, %gx)\n", m.test.Id, state, m.speed)
+	builder.WriteString(m.game.View())
+	builder.WriteString("press space to pause, + and - to change speed, r to restart the replay\n")
+	builder.WriteString("press esc to exit the replay\n")
+
+	return builder.String()
+}
+
+// RunReplay implements the replay subcommand which plays back a recorded
+// test outside of the main menu.
+func RunReplay(paths Paths, args []string) error {
+	cmd := flag.NewFlagSet("replay", flag.ExitOnError)
+
+	if err := cmd.Parse(args); err != nil {
+		return err
+	}
+
+	if cmd.NArg() != 1 {
+		return errors.New("usage: racer replay <test-id>")
+	}
+
+	id, err := strconv.Atoi(cmd.Arg(0))
+
+	if err != nil {
+		return fmt.Errorf("invalid test id %s", cmd.Arg(0))
+	}
+
+	config, err := ReadOrCreateConfig(paths)
+
+	if err != nil {
+		return err
+	}
+
+	store, err := OpenDuckDBStore(config.paths.DbFile)
+
+	if err != nil {
+		return err
+	}
+
+	defer store.Close()
+
+	test, err := store.GetTest(id)
+
+	if err != nil {
+		return err
+	}
+
+	quoteDb, err := LoadQuoteDb(config.paths.QuotesDir())
+
+	if err != nil {
+		return err
+	}
+
+	replay, err := NewReplayModel(config, quoteDb, test)
+
+	if err != nil {
+		return err
+	}
+
+	replay.quitOnExit = true
+
+	_, err = tea.NewProgram(replay, tea.WithAltScreen()).Run()
+
+	return err
+}
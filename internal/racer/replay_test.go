@@ -0,0 +1,190 @@
+package racer
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+)
+
+func TestReplayAdvance(t *testing.T) {
+	test := &RacerTest{
+		Id: 1,
+		Test: "english",
+		Mode: "words",
+		TestSize: 2,
+		AllowBackspace: true,
+		Target: "ab cd",
+		Keystrokes: []engine.Keystroke{
+			{ Offset: 100*time.Millisecond, Expected: 'a', Typed: 'a', Op: "m" },
+			{ Offset: 200*time.Millisecond, Expected: 'b', Typed: 'x', Op: "s" },
+			{ Offset: 300*time.Millisecond, Expected: 'b', Typed: 'x', Op: "d" },
+			{ Offset: 400*time.Millisecond, Expected: 'b', Typed: 'b', Op: "m" },
+			{ Offset: 1500*time.Millisecond, Expected: ' ', Typed: ' ', Op: "m" },
+		},
+	}
+
+	m, err := NewReplayModel(DefaultConfig2(), nil, test)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.advance(250*time.Millisecond)
+
+	if got := string(m.game.session.Input()); got != "ax" {
+		t.Errorf("incorrect input after 250ms got %q wanted %q", got, "ax")
+	}
+
+	m.advance(time.Second)
+
+	if got := string(m.game.session.Input()); got != "ab" {
+		t.Errorf("incorrect input after 1250ms got %q wanted %q", got, "ab")
+	}
+
+	if n := len(m.game.session.Samples()); n != 1 {
+		t.Errorf("incorrect number of samples got %d wanted %d", n, 1)
+	}
+
+	if m.game.finished {
+		t.Errorf("replay finished before all keystrokes were applied")
+	}
+
+	m.advance(time.Second)
+
+	if !m.game.finished {
+		t.Errorf("replay not finished after all keystrokes were applied")
+	}
+}
+
+func TestReplayRejectedKeys(t *testing.T) {
+	test := &RacerTest{
+		Id: 1,
+		Test: "english",
+		Mode: "words",
+		TestSize: 1,
+		Target: "ab",
+		Keystrokes: []engine.Keystroke{
+			{ Offset: 100*time.Millisecond, Expected: 'a', Typed: 'a', Op: "m" },
+			{ Offset: 200*time.Millisecond, Expected: 'b', Typed: 'x', Op: "r" },
+			{ Offset: 300*time.Millisecond, Expected: 'b', Typed: 'b', Op: "m" },
+		},
+	}
+
+	m, err := NewReplayModel(DefaultConfig2(), nil, test)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.advance(time.Second)
+
+	s := m.game.session
+
+	if got := string(s.Input()); got != "ab" || s.Rejected() != 1 || s.Alignment().Rle() != "mrm" {
+		t.Errorf("replay of rejected keys got input %q with %d rejected and rle %s", got, s.Rejected(), s.Alignment().Rle())
+	}
+}
+
+func TestReplayStopOnWord(t *testing.T) {
+	// stop on word lets the current word be corrected without backspace
+	s := engine.NewSession("ab cd", engine.Options{ Stop: engine.StopOnWord })
+	at := time.Duration(0)
+
+	for _, char := range "ax <b cd" {
+		at += 100*time.Millisecond
+
+		if char == '<' {
+			s.Backspace(at)
+		} else {
+			s.Type(char, at)
+		}
+	}
+
+	if got, rle := string(s.Input()), s.Alignment().Rle(); got != "ab cd" || rle != "msrd4m" {
+		t.Fatalf("recorded input got %q with rle %s wanted ab cd with msrd4m", got, rle)
+	}
+
+	for name, store := range newTestStores(t) {
+		recorded := storeTest(50, 2, false)
+		recorded.Target = "ab cd"
+		recorded.Input = string(s.Input())
+		recorded.StopOnError = "word"
+		recorded.Keystrokes = s.Keystrokes()
+
+		if err := store.InsertTest(recorded); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		test, err := store.GetTest(recorded.Id)
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		m, err := NewReplayModel(DefaultConfig2(), nil, test)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m.advance(time.Second)
+
+		replayed := m.game.session
+
+		if got, rle := string(replayed.Input()), replayed.Alignment().Rle(); got != recorded.Input || rle != s.Alignment().Rle() {
+			t.Errorf("%s: replay got input %q with rle %s wanted %q with %s", name, got, rle, recorded.Input, s.Alignment().Rle())
+		}
+	}
+}
+
+func TestReplayQuote(t *testing.T) {
+	quoteDb, err := LoadQuoteDb("quotes")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, _ := quoteDb.Get("english")
+	quote := l.Filter("short")[0]
+
+	s := engine.NewSession(quote.Text, engine.Options{})
+	at := time.Duration(0)
+
+	for _, char := range quote.Text {
+		at += 100*time.Millisecond
+		s.Type(char, at)
+	}
+
+	test := &RacerTest{
+		Id: 1,
+		Test: "english",
+		Mode: "quote",
+		QuoteId: quote.Id,
+		QuoteLength: quote.Length,
+		Target: quote.Text,
+		Input: string(s.Input()),
+		Keystrokes: s.Keystrokes(),
+	}
+
+	m, err := NewReplayModel(DefaultConfig2(), quoteDb, test)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.game.setWidth(100)
+	m.advance(at + time.Second)
+
+	if !m.game.finished {
+		t.Fatalf("replay of the quote not finished")
+	}
+
+	if got := m.game.quoteId(); got != quote.Id {
+		t.Errorf("incorrect replayed quote got %d wanted %d", got, quote.Id)
+	}
+
+	if view, want := m.View(), "quote: " + quote.Source; !strings.Contains(view, want) {
+		t.Errorf("replay view got %q wanted it to contain %q", view, want)
+	}
+}
@@ -0,0 +1,426 @@
+package racer
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"github.com/arjunmoola/go-racer/internal/engine"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	reviewMode = "review"
+	initialEase = 2.5
+	minEase = 1.3
+	// a review test holds at most reviewTestSize of the due words and
+	// repeats them until it is at least reviewMinTestSize words long.
+	reviewTestSize = 50
+	reviewMinTestSize = 10
+)
+
+// ReviewWord is a mistyped word and its SM-2 schedule. Interval is the
+// number of days after ReviewedAt the word is due again.
+type ReviewWord struct {
+	Word string
+	Ease float64
+	Interval int
+	Repetitions int
+	Lapses int
+	Due time.Time
+	ReviewedAt time.Time
+}
+
+// ReviewSummary is the state of the review queue.
+type ReviewSummary struct {
+	Due int
+	ReviewedToday int
+	Words int
+}
+
+// review schedules the word after it was typed with grade, from 0 for a
+// complete failure to 5 for a perfect recall.
+func (w *ReviewWord) review(grade int, now time.Time) {
+	if grade >= 3 {
+		switch w.Repetitions {
+		case 0:
+			w.Interval = 1
+		case 1:
+			w.Interval = 6
+		default:
+			w.Interval = int(math.Round(float64(w.Interval)*w.Ease))
+		}
+
+		w.Repetitions++
+	} else {
+		w.Repetitions = 0
+		w.Interval = 1
+		w.Lapses++
+	}
+
+	q := float64(5 - grade)
+	w.Ease = max(w.Ease + 0.1 - q*(0.08 + q*0.02), minEase)
+	w.Due = now.AddDate(0, 0, w.Interval)
+	w.ReviewedAt = now
+}
+
+// reviewGrade grades a typed word: 5 without mistakes, 3 when the mistakes
+// were corrected and 1 when the word was left mistyped.
+func reviewGrade(result engine.WordResult) int {
+	switch {
+	case !result.Correct:
+		return 1
+	case result.Mistakes > 0:
+		return 3
+	default:
+		return 5
+	}
+}
+
+// reviewWord strips the punctuation and capitals that modifiers add to a
+// word. Words with digits come from the numbers modifier and are not
+// reviewed.
+func reviewWord(word string) (string, bool) {
+	word = strings.ToLower(strings.TrimFunc(word, unicode.IsPunct))
+
+	if word == "" || strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+		return "", false
+	}
+
+	return word, true
+}
+
+// gradeWords returns the worst grade of every word in results.
+func gradeWords(results []engine.WordResult) map[string]int {
+	grades := make(map[string]int)
+
+	for _, result := range results {
+		word, ok := reviewWord(result.Word)
+
+		if !ok {
+			continue
+		}
+
+		grade := reviewGrade(result)
+
+		if prev, ok := grades[word]; !ok || grade < prev {
+			grades[word] = grade
+		}
+	}
+
+	return grades
+}
+
+// reviewUpdate returns the schedule of word after it was typed with grade,
+// or nil when the schedule does not change. Words are only tracked once
+// they are mistyped and typing a word correctly before it is due does not
+// count as a review, mistakes always do.
+func reviewUpdate(word *ReviewWord, name string, grade int, now time.Time) *ReviewWord {
+	if word == nil {
+		if grade == 5 {
+			return nil
+		}
+
+		word = &ReviewWord{ Word: name, Ease: initialEase }
+	} else if grade >= 3 && now.Before(word.Due) {
+		return nil
+	}
+
+	updated := *word
+	updated.review(grade, now)
+
+	return &updated
+}
+
+// sortedGrades orders the graded words so stores update them in the same
+// order.
+func sortedGrades(grades map[string]int) []string {
+	words := make([]string, 0, len(grades))
+
+	for word := range grades {
+		words = append(words, word)
+	}
+
+	slices.Sort(words)
+
+	return words
+}
+
+// startOfDay is the midnight before now in the local time zone.
+func startOfDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+const reviewWordColumns = "word, ease, interval_days, repetitions, lapses, due_at, reviewed_at"
+
+const getReviewWordQueryStr = "SELECT " + reviewWordColumns + " FROM review_words WHERE word = ?"
+
+const upsertReviewWordStmtStr = "INSERT OR REPLACE INTO review_words (" + reviewWordColumns + ") VALUES(?, ?, ?, ?, ?, ?, ?)"
+
+const getDueWordsQueryStr = `
+	SELECT ` + reviewWordColumns + `
+	FROM review_words
+	WHERE due_at <= ?
+	ORDER BY due_at, word
+	LIMIT ?
+	`
+
+const reviewSummaryQueryStr = `
+	SELECT
+		count(*) FILTER (WHERE due_at <= ?),
+		count(*) FILTER (WHERE reviewed_at >= ?),
+		count(*)
+	FROM review_words
+	`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReviewWord(row rowScanner) (*ReviewWord, error) {
+	word := &ReviewWord{}
+
+	err := row.Scan(
+		&word.Word,
+		&word.Ease,
+		&word.Interval,
+		&word.Repetitions,
+		&word.Lapses,
+		&word.Due,
+		&word.ReviewedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return word, nil
+}
+
+// ReviewWords updates the schedule of every word in results as one
+// transaction.
+func ReviewWords(db *sql.DB, results []engine.WordResult, now time.Time) error {
+	grades := gradeWords(results)
+
+	if len(grades) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	for _, name := range sortedGrades(grades) {
+		word, err := scanReviewWord(tx.QueryRow(getReviewWordQueryStr, name))
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		updated := reviewUpdate(word, name, grades[name], now)
+
+		if updated == nil {
+			continue
+		}
+
+		_, err = tx.Exec(
+			upsertReviewWordStmtStr,
+			updated.Word,
+			updated.Ease,
+			updated.Interval,
+			updated.Repetitions,
+			updated.Lapses,
+			updated.Due,
+			updated.ReviewedAt,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func GetDueWords(db *sql.DB, now time.Time, limit int) ([]*ReviewWord, error) {
+	rows, err := db.Query(getDueWordsQueryStr, now, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var words []*ReviewWord
+
+	for rows.Next() {
+		word, err := scanReviewWord(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		words = append(words, word)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return words, nil
+}
+
+func GetReviewSummary(db *sql.DB, now time.Time) (*ReviewSummary, error) {
+	summary := &ReviewSummary{}
+
+	row := db.QueryRow(reviewSummaryQueryStr, now, startOfDay(now))
+
+	if err := row.Scan(&summary.Due, &summary.ReviewedToday, &summary.Words); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+func (s *DuckDBStore) ReviewWords(results []engine.WordResult, now time.Time) error {
+	return ReviewWords(s.db, results, now)
+}
+
+func (s *DuckDBStore) GetDueWords(now time.Time, limit int) ([]*ReviewWord, error) {
+	return GetDueWords(s.db, now, limit)
+}
+
+func (s *DuckDBStore) GetReviewSummary(now time.Time) (*ReviewSummary, error) {
+	return GetReviewSummary(s.db, now)
+}
+
+func (s *MemoryStore) ReviewWords(results []engine.WordResult, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	grades := gradeWords(results)
+
+	for _, name := range sortedGrades(grades) {
+		if updated := reviewUpdate(s.reviews[name], name, grades[name], now); updated != nil {
+			s.reviews[name] = updated
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) GetDueWords(now time.Time, limit int) ([]*ReviewWord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var words []*ReviewWord
+
+	for _, word := range s.reviews {
+		if !word.Due.After(now) {
+			c := *word
+			words = append(words, &c)
+		}
+	}
+
+	slices.SortFunc(words, func(a, b *ReviewWord) int {
+		if c := a.Due.Compare(b.Due); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Word, b.Word)
+	})
+
+	return words[:min(limit, len(words))], nil
+}
+
+func (s *MemoryStore) GetReviewSummary(now time.Time) (*ReviewSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := &ReviewSummary{ Words: len(s.reviews) }
+	today := startOfDay(now)
+
+	for _, word := range s.reviews {
+		if !word.Due.After(now) {
+			summary.Due++
+		}
+
+		if !word.ReviewedAt.Before(today) {
+			summary.ReviewedToday++
+		}
+	}
+
+	return summary, nil
+}
+
+// reviewTestWords shuffles the due words into the words of a review test.
+func reviewTestWords(rng *rand.Rand, due []*ReviewWord) []string {
+	var words []string
+
+	for _, word := range due[:min(len(due), reviewTestSize)] {
+		words = append(words, word.Word)
+	}
+
+	for i := 0; len(words) > 0 && len(words) < reviewMinTestSize; i++ {
+		words = append(words, words[i])
+	}
+
+	rng.Shuffle(len(words), func(i, j int) {
+		words[i], words[j] = words[j], words[i]
+	})
+
+	return words
+}
+
+type reviewQueueErr error
+type reviewQueueMsg struct {
+	due []*ReviewWord
+	summary *ReviewSummary
+}
+
+func (r *RacerModel) reviewQueue() tea.Msg {
+	now := time.Now()
+
+	due, err := r.store.GetDueWords(now, reviewTestSize)
+
+	if err != nil {
+		return reviewQueueErr(err)
+	}
+
+	summary, err := r.store.GetReviewSummary(now)
+
+	if err != nil {
+		return reviewQueueErr(err)
+	}
+
+	return reviewQueueMsg{
+		due: due,
+		summary: summary,
+	}
+}
+
+func (r *RacerModel) getReviewQueueCmd() tea.Cmd {
+	return r.reviewQueue
+}
+
+// reviewTestCmd schedules the words of a finished test and reloads the
+// review queue.
+func (r *RacerModel) reviewTestCmd(target string, keystrokes engine.KeystrokeLog) tea.Cmd {
+	return func() tea.Msg {
+		results := engine.WordResults([]rune(target), keystrokes)
+
+		if err := r.store.ReviewWords(results, time.Now()); err != nil {
+			return reviewQueueErr(err)
+		}
+
+		return r.reviewQueue()
+	}
+}
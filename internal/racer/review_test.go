@@ -0,0 +1,132 @@
+package racer
+
+import (
+	"testing"
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+)
+
+func TestReviewSchedule(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	word := &ReviewWord{ Word: "their", Ease: initialEase }
+
+	for i, want := range []int{ 1, 6, 16 } {
+		word.review(5, now)
+
+		if word.Interval != want || !word.Due.Equal(now.AddDate(0, 0, want)) {
+			t.Errorf("review %d got interval %d due %v wanted %d", i, word.Interval, word.Due, want)
+		}
+	}
+
+	ease := word.Ease
+	word.review(1, now)
+
+	if word.Interval != 1 || word.Repetitions != 0 || word.Lapses != 1 || word.Ease >= ease {
+		t.Errorf("failed review got %+v", word)
+	}
+
+	for range 20 {
+		word.review(0, now)
+	}
+
+	if word.Ease != minEase {
+		t.Errorf("ease got %v wanted at least %v", word.Ease, minEase)
+	}
+}
+
+func TestReviewWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+		ok bool
+	}{
+		{ "Delta,", "delta", true },
+		{ "\"echo\".", "echo", true },
+		{ "1984", "", false },
+		{ "...", "", false },
+	}
+
+	for _, test := range tests {
+		if got, ok := reviewWord(test.word); got != test.want || ok != test.ok {
+			t.Errorf("reviewWord(%q) got %q %v wanted %q %v", test.word, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestStoreReviewWords(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+
+	for name, store := range newTestStores(t) {
+		results := []engine.WordResult{
+			{ Word: "the", Correct: true },
+			{ Word: "cat", Mistakes: 1, Correct: false },
+			{ Word: "sat", Mistakes: 1, Correct: true },
+			{ Word: "Cat", Correct: true },
+		}
+
+		if err := store.ReviewWords(results, now); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		due, err := store.GetDueWords(now, 10)
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(due) != 0 {
+			t.Errorf("%s: words due right after their review got %d", name, len(due))
+		}
+
+		tomorrow := now.AddDate(0, 0, 1)
+
+		due, err = store.GetDueWords(tomorrow, 10)
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(due) != 2 || due[0].Word != "cat" || due[0].Lapses != 1 || due[1].Word != "sat" {
+			t.Fatalf("%s: due words got %+v wanted cat and sat", name, due)
+		}
+
+		// typing a word correctly before it is due does not move it
+		if err := store.ReviewWords([]engine.WordResult{ { Word: "cat", Correct: true } }, now.Add(time.Hour)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if err := store.ReviewWords([]engine.WordResult{ { Word: "sat", Correct: true } }, tomorrow); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		summary, err := store.GetReviewSummary(tomorrow)
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if *summary != (ReviewSummary{ Due: 1, ReviewedToday: 1, Words: 2 }) {
+			t.Errorf("%s: review summary got %+v", name, summary)
+		}
+	}
+}
+
+func TestReviewTestWords(t *testing.T) {
+	due := []*ReviewWord{ { Word: "cat" }, { Word: "sat" }, { Word: "mat" } }
+
+	words := reviewTestWords(newRand(3), due)
+
+	if len(words) != reviewMinTestSize {
+		t.Errorf("review test got %d words wanted %d", len(words), reviewMinTestSize)
+	}
+
+	counts := make(map[string]int)
+
+	for _, word := range words {
+		counts[word]++
+	}
+
+	if counts["cat"] < 3 || counts["sat"] < 3 || counts["mat"] < 3 {
+		t.Errorf("review test does not repeat every word got %v", counts)
+	}
+}
@@ -0,0 +1,215 @@
+package racer
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"unicode"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// the position of a strategy in this list is part of the challenge code
+// format, so new strategies must only ever be appended.
+var samplingOptions = []string{ "uniform", "zipf", "top", "no repeat" }
+
+const (
+	samplingUniform = "uniform"
+	samplingZipf = "zipf"
+	samplingTop = "top"
+	samplingNoRepeat = "no repeat"
+
+	defaultSampling = samplingUniform
+	defaultSamplingTopN = 200
+	defaultSamplingWindow = 10
+)
+
+// wordSampler picks the index of the next word of a test.
+type wordSampler func(rng *rand.Rand) int
+
+func uniformSampler(n int) wordSampler {
+	return func(rng *rand.Rand) int {
+		return rng.IntN(n)
+	}
+}
+
+// weightedSampler picks every index with a probability proportional to its
+// weight.
+func weightedSampler(weights []float64) wordSampler {
+	cumulative := make([]float64, len(weights))
+	total := 0.0
+
+	for i, w := range weights {
+		total += w
+		cumulative[i] = total
+	}
+
+	return func(rng *rand.Rand) int {
+		x := rng.Float64()*total
+		idx := sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > x })
+		return min(idx, len(cumulative) - 1)
+	}
+}
+
+// noRepeatSampler draws from sampler until it picks a word that is not one
+// of the last window words. The window is shrunk for short lists so there
+// is always a word left to pick.
+func noRepeatSampler(sampler wordSampler, n int, window int) wordSampler {
+	window = min(window, n - 1)
+	var recent []int
+
+	return func(rng *rand.Rand) int {
+		idx := sampler(rng)
+
+		// a weighted sampler may give the words outside the window a tiny
+		// chance, so give up eventually and repeat a word.
+		for tries := 0; slices.Contains(recent, idx) && tries < 100; tries++ {
+			idx = sampler(rng)
+		}
+
+		if window > 0 {
+			recent = append(recent, idx)
+
+			if len(recent) > window {
+				recent = recent[1:]
+			}
+		}
+
+		return idx
+	}
+}
+
+// samplingWeights returns the weight of every word of list under strategy,
+// nil when every word is equally likely. Zipf and top only apply to lists
+// ordered by frequency, other lists are sampled uniformly.
+func samplingWeights(list *WordList, strategy string, size int) []float64 {
+	if !list.OrderedByFrequency {
+		return nil
+	}
+
+	switch strategy {
+	case samplingZipf:
+		weights := make([]float64, len(list.Words))
+
+		for i := range weights {
+			weights[i] = 1/float64(i + 1)
+		}
+
+		return weights
+	case samplingTop:
+		if size <= 0 || size >= len(list.Words) {
+			return nil
+		}
+
+		weights := make([]float64, len(list.Words))
+
+		for i := range size {
+			weights[i] = 1
+		}
+
+		return weights
+	}
+
+	return nil
+}
+
+// listSampling returns the strategy and size the words of list are really
+// sampled with. Zipf and top fall back to uniform for lists that are not
+// ordered by frequency and top does for a size that covers the whole list,
+// tests have to be recorded with the strategy they were sampled with.
+func listSampling(list *WordList, strategy string, size int) (string, int) {
+	switch strategy {
+	case samplingZipf:
+		if list.OrderedByFrequency {
+			return strategy, 0
+		}
+	case samplingTop:
+		if list.OrderedByFrequency && size > 0 && size < len(list.Words) {
+			return strategy, size
+		}
+	case samplingNoRepeat:
+		return strategy, size
+	}
+
+	return samplingUniform, 0
+}
+
+// samplingSize is the parameter of strategy in config.
+func samplingSize(config *Config2, strategy string) int {
+	switch strategy {
+	case samplingTop:
+		return config.SamplingTopN
+	case samplingNoRepeat:
+		return config.SamplingWindow
+	}
+
+	return 0
+}
+
+// newWordSampler creates the sampler for a test drawing from list. The
+// weights of adaptive tests are applied on top of the strategy, nil leaves
+// them out.
+func newWordSampler(list *WordList, strategy string, size int, adaptive []float64) wordSampler {
+	n := len(list.Words)
+	weights := samplingWeights(list, strategy, size)
+
+	if adaptive != nil {
+		if weights == nil {
+			weights = adaptive
+		} else {
+			for i := range weights {
+				weights[i] *= adaptive[i]
+			}
+		}
+	}
+
+	sampler := uniformSampler(n)
+
+	if weights != nil {
+		sampler = weightedSampler(weights)
+	}
+
+	if strategy == samplingNoRepeat {
+		sampler = noRepeatSampler(sampler, n, size)
+	}
+
+	return sampler
+}
+
+// stripDiacritics removes the accents from s, so lazy mode turns "café"
+// into "cafe".
+func stripDiacritics(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, s)
+
+	if err != nil {
+		return s
+	}
+
+	return result
+}
+
+// lazyMode reports whether lazy mode applies to the words of list, lists
+// of languages where the accents make a different word opt out of it.
+func lazyMode(list *WordList, lazy bool) bool {
+	return lazy && !list.NoLazyMode
+}
+
+// samplingLabel describes how the words of a test were sampled, for
+// example "top 200" or "zipf lazy".
+func samplingLabel(sampling string, size int, lazy bool) string {
+	label := cmp.Or(sampling, samplingUniform)
+
+	if size > 0 {
+		label = fmt.Sprintf("%s %d", label, size)
+	}
+
+	if lazy {
+		label += " lazy"
+	}
+
+	return label
+}
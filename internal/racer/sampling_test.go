@@ -0,0 +1,156 @@
+package racer
+
+import (
+	"slices"
+	"testing"
+)
+
+func frequencyList(n int) *WordList {
+	list := &WordList{ Name: "frequency", OrderedByFrequency: true }
+
+	for i := range n {
+		list.Words = append(list.Words, string(rune('a' + i)))
+	}
+
+	return list
+}
+
+func TestSamplingWeights(t *testing.T) {
+	list := frequencyList(4)
+
+	if got, want := samplingWeights(list, samplingZipf, 0), []float64{ 1, 0.5, 1.0/3, 0.25 }; !slices.Equal(got, want) {
+		t.Errorf("zipf weights got %v wanted %v", got, want)
+	}
+
+	if got, want := samplingWeights(list, samplingTop, 2), []float64{ 1, 1, 0, 0 }; !slices.Equal(got, want) {
+		t.Errorf("top weights got %v wanted %v", got, want)
+	}
+
+	if got := samplingWeights(list, samplingTop, 10); got != nil {
+		t.Errorf("top weights larger than the list got %v wanted uniform", got)
+	}
+
+	list.OrderedByFrequency = false
+
+	if got := samplingWeights(list, samplingZipf, 0); got != nil {
+		t.Errorf("zipf weights of an unordered list got %v wanted uniform", got)
+	}
+}
+
+func TestWordSampler(t *testing.T) {
+	list := frequencyList(10)
+	rng := newRand(3)
+
+	top := newWordSampler(list, samplingTop, 3, nil)
+
+	for range 1000 {
+		if idx := top(rng); idx >= 3 {
+			t.Fatalf("top sampler picked word %d outside the top 3", idx)
+		}
+	}
+
+	noRepeat := newWordSampler(list, samplingNoRepeat, 4, nil)
+	var picked []int
+
+	for range 1000 {
+		idx := noRepeat(rng)
+
+		if slices.Contains(picked[max(len(picked) - 4, 0):], idx) {
+			t.Fatalf("no repeat sampler picked %d again within %v", idx, picked[len(picked) - 4:])
+		}
+
+		picked = append(picked, idx)
+	}
+
+	// the window can not hold every word of a short list
+	short := newWordSampler(frequencyList(2), samplingNoRepeat, 10, nil)
+
+	for i := range 10 {
+		if a, b := short(rng), short(rng); a == b {
+			t.Fatalf("no repeat sampler of two words repeated %d at %d", a, i)
+		}
+	}
+
+	// adaptive weights apply on top of the strategy
+	adaptive := newWordSampler(list, samplingTop, 2, []float64{ 0, 1, 1, 1, 1, 1, 1, 1, 1, 1 })
+
+	for range 100 {
+		if idx := adaptive(rng); idx != 1 {
+			t.Fatalf("adaptive top sampler picked %d wanted 1", idx)
+		}
+	}
+}
+
+func TestLazyMode(t *testing.T) {
+	if got, want := stripDiacritics("café naïve Ångström łódź"), "cafe naive Angstrom łodz"; got != want {
+		t.Errorf("stripDiacritics got %q wanted %q", got, want)
+	}
+
+	list := &WordList{ Name: "français", Words: []string{ "été" } }
+
+	if !lazyMode(list, true) || lazyMode(list, false) {
+		t.Errorf("lazy mode not applied to %s", list.Name)
+	}
+
+	list.NoLazyMode = true
+
+	if lazyMode(list, true) {
+		t.Errorf("lazy mode applied to a list that does not allow it")
+	}
+}
+
+func TestListSampling(t *testing.T) {
+	ordered := frequencyList(10)
+	unordered := &WordList{ Name: "unordered", Words: ordered.Words }
+
+	tests := []struct {
+		list *WordList
+		strategy string
+		size int
+		want string
+		wantSize int
+	}{
+		{ ordered, samplingZipf, 0, samplingZipf, 0 },
+		{ unordered, samplingZipf, 0, samplingUniform, 0 },
+		{ ordered, samplingTop, 5, samplingTop, 5 },
+		{ ordered, samplingTop, 200, samplingUniform, 0 },
+		{ unordered, samplingTop, 5, samplingUniform, 0 },
+		{ unordered, samplingNoRepeat, 3, samplingNoRepeat, 3 },
+		{ ordered, "", 0, samplingUniform, 0 },
+	}
+
+	for _, test := range tests {
+		got, size := listSampling(test.list, test.strategy, test.size)
+
+		if got != test.want || size != test.wantSize {
+			t.Errorf("listSampling(%s, %s, %d) got %s %d wanted %s %d", test.list.Name, test.strategy, test.size, got, size, test.want, test.wantSize)
+		}
+	}
+
+	// a test of an unordered list is recorded as uniform
+	words := &WordList{ Name: "test", Words: []string{ "alpha", "bravo", "charlie" } }
+	config := DefaultConfig2()
+	config.TestName = words.Name
+	config.GameMode = "words"
+	config.Sampling = samplingTop
+	config.SamplingTopN = 2
+
+	r := &RacerModel{
+		config: config,
+		wordDb: &WordDb{ wordLists: map[string]*WordList{ words.Name: words } },
+		quoteDb: &QuoteDb{ quoteLists: map[string]*QuoteList{} },
+	}
+	r.game = NewGameFromConfig(config)
+	r.game.racer = r
+	r.game.createTest()
+
+	params := r.game.bestParams()
+
+	if params.sampling != samplingUniform || params.samplingSize != 0 || r.game.challenge().Sampling != samplingUniform {
+		t.Errorf("test of an unordered list got sampling %s %d wanted uniform", params.sampling, params.samplingSize)
+	}
+
+	if got := configBestParams(config, r.wordDb); got.key() != params.key() {
+		t.Errorf("ghost lookup got %+v wanted %+v", got.key(), params.key())
+	}
+}
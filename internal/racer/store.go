@@ -0,0 +1,132 @@
+package racer
+
+import (
+	"database/sql"
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+)
+
+// Store is where racer keeps completed tests, the game stats and the
+// player. RacerModel only talks to its store so the game can be run
+// against an in-memory store in tests.
+type Store interface {
+	// InsertTest saves a test and its keystrokes and sets test.Id.
+	InsertTest(test *RacerTest) error
+	// GetTest returns a test with its keystrokes. It returns an error
+	// wrapping ErrTestNotFound when there is no test with the id.
+	GetTest(id int) (*RacerTest, error)
+	// GetAllTests returns the 100 most recent tests, newest first, without
+	// their keystrokes.
+	GetAllTests() ([]*RacerTest, error)
+	// GetTests returns the page of tests selected by query without their
+	// keystrokes.
+	GetTests(query *TestQuery) (*TestPage, error)
+	// GetBestTest returns the highest wpm test with keystrokes taken with
+	// params.
+	GetBestTest(params *PersonalBestParams) (*RacerTest, bool, error)
+	GetPersonalBests() ([]*PersonalBest, error)
+	GetPersonalBest(params *PersonalBestParams) (*PersonalBest, bool, error)
+	// GetDashboard summarizes the tests matching filter.
+	GetDashboard(filter *StatsFilter) (*Dashboard, error)
+	// GetKeyStats aggregates the keystrokes of the tests matching filter by
+	// character and bigram.
+	GetKeyStats(filter *StatsFilter) (*engine.KeyStats, error)
+	// HasImportedTest reports whether a test with externalId was already
+	// imported from source.
+	HasImportedTest(source, externalId string) (bool, error)
+
+	// ReviewWords updates the review schedule of the words of a test.
+	ReviewWords(results []engine.WordResult, now time.Time) error
+	// GetDueWords returns up to limit words that are due for review, the
+	// longest overdue first.
+	GetDueWords(now time.Time, limit int) ([]*ReviewWord, error)
+	GetReviewSummary(now time.Time) (*ReviewSummary, error)
+
+	GetGameStats() (*GameStats, error)
+	UpdateGameStats(stats *GameStats) error
+
+	GetPlayerInfo() (*PlayerInfo, bool, error)
+	InsertPlayerInfo(params *PlayerInfoInsertParams) error
+
+	Close() error
+}
+
+var (
+	_ Store = (*DuckDBStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
+
+// DuckDBStore keeps everything in a duckdb database file.
+type DuckDBStore struct {
+	db *sql.DB
+}
+
+// OpenDuckDBStore opens the database at path, migrating it to the current
+// schema.
+func OpenDuckDBStore(path string) (*DuckDBStore, error) {
+	db, err := SetupDB(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDuckDBStore(db), nil
+}
+
+func NewDuckDBStore(db *sql.DB) *DuckDBStore {
+	return &DuckDBStore{ db: db }
+}
+
+// DB returns the underlying database for queries that are not part of the
+// Store interface.
+func (s *DuckDBStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *DuckDBStore) InsertTest(test *RacerTest) error {
+	return InsertRacerTest(s.db, test)
+}
+
+func (s *DuckDBStore) GetTest(id int) (*RacerTest, error) {
+	return GetRacerTest(s.db, id)
+}
+
+func (s *DuckDBStore) GetAllTests() ([]*RacerTest, error) {
+	return GetAllTests(s.db)
+}
+
+func (s *DuckDBStore) GetBestTest(params *PersonalBestParams) (*RacerTest, bool, error) {
+	return GetBestTest(s.db, params)
+}
+
+func (s *DuckDBStore) GetPersonalBests() ([]*PersonalBest, error) {
+	return GetPersonalBests(s.db)
+}
+
+func (s *DuckDBStore) GetPersonalBest(params *PersonalBestParams) (*PersonalBest, bool, error) {
+	return GetPersonalBest(s.db, params)
+}
+
+func (s *DuckDBStore) HasImportedTest(source, externalId string) (bool, error) {
+	return HasImportedTest(s.db, source, externalId)
+}
+
+func (s *DuckDBStore) GetGameStats() (*GameStats, error) {
+	return GetGameStats(s.db)
+}
+
+func (s *DuckDBStore) UpdateGameStats(stats *GameStats) error {
+	return UpdateGameStats(s.db, stats)
+}
+
+func (s *DuckDBStore) GetPlayerInfo() (*PlayerInfo, bool, error) {
+	return GetPlayerInfo(s.db)
+}
+
+func (s *DuckDBStore) InsertPlayerInfo(params *PlayerInfoInsertParams) error {
+	return InsertPlayerInfo(s.db, params)
+}
+
+func (s *DuckDBStore) Close() error {
+	return s.db.Close()
+}
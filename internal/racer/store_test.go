@@ -0,0 +1,355 @@
+package racer
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	"github.com/arjunmoola/go-racer/internal/engine"
+)
+
+func newTestStores(t *testing.T) map[string]Store {
+	duck, err := OpenDuckDBStore(filepath.Join(t.TempDir(), "racer.db"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { duck.Close() })
+
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"duckdb": duck,
+	}
+}
+
+func storeTest(wpm int, size int, keystrokes bool) *RacerTest {
+	test := &RacerTest{
+		Test: "english",
+		Mode: "words",
+		TestSize: size,
+		Target: "alpha bravo",
+		Input: "alpha brovo",
+		Wpm: wpm,
+		Accuracy: 90,
+	}
+
+	if keystrokes {
+		test.Keystrokes = []engine.Keystroke{
+			{ Offset: time.Second, Expected: 'a', Typed: 'a', Op: engine.OpMatch },
+		}
+	}
+
+	return test
+}
+
+func TestStoreTests(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		tests := []*RacerTest{
+			storeTest(50, 25, true),
+			storeTest(70, 25, false),
+			storeTest(60, 25, true),
+			storeTest(40, 50, true),
+		}
+
+		for _, test := range tests {
+			if err := store.InsertTest(test); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+
+		got, err := store.GetTest(tests[0].Id)
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if got.Wpm != 50 || len(got.Keystrokes) != 1 || got.CreatedAt.IsZero() {
+			t.Errorf("%s: GetTest got %+v wanted %+v", name, got, tests[0])
+		}
+
+		if _, err := store.GetTest(1000); !errors.Is(err, ErrTestNotFound) {
+			t.Errorf("%s: GetTest of missing test got error %v wanted %v", name, err, ErrTestNotFound)
+		}
+
+		all, err := store.GetAllTests()
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(all) != 4 || all[0].Id != tests[3].Id {
+			t.Errorf("%s: GetAllTests not newest first got %d tests", name, len(all))
+		}
+
+		params := tests[0].bestParams()
+
+		best, found, err := store.GetBestTest(params)
+
+		if err != nil || !found {
+			t.Fatalf("%s: best test not found: %v", name, err)
+		}
+
+		if best.Id != tests[2].Id {
+			t.Errorf("%s: GetBestTest got id %d wanted %d", name, best.Id, tests[2].Id)
+		}
+
+		pb, found, err := store.GetPersonalBest(params)
+
+		if err != nil || !found {
+			t.Fatalf("%s: personal best not found: %v", name, err)
+		}
+
+		if pb.Wpm != 70 || pb.TestId != tests[1].Id || pb.Attempts != 3 || pb.Size != 25 {
+			t.Errorf("%s: GetPersonalBest got %+v", name, pb)
+		}
+
+		bests, err := store.GetPersonalBests()
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(bests) != 2 || bests[0].Size != 25 || bests[1].Size != 50 {
+			t.Errorf("%s: GetPersonalBests got %d bests", name, len(bests))
+		}
+	}
+}
+
+func TestStoreSampling(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		uniform := storeTest(50, 25, true)
+		top := storeTest(90, 25, true)
+		top.Sampling = samplingTop
+		top.SamplingSize = 200
+		lazy := storeTest(80, 25, true)
+		lazy.Sampling = samplingTop
+		lazy.SamplingSize = 200
+		lazy.Lazy = true
+
+		for _, test := range []*RacerTest{ uniform, top, lazy } {
+			if err := store.InsertTest(test); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+
+		got, err := store.GetTest(lazy.Id)
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if got.Sampling != samplingTop || got.SamplingSize != 200 || !got.Lazy {
+			t.Errorf("%s: GetTest sampling got %s %d %v", name, got.Sampling, got.SamplingSize, got.Lazy)
+		}
+
+		for _, test := range []*RacerTest{ uniform, top, lazy } {
+			best, found, err := store.GetBestTest(test.bestParams())
+
+			if err != nil || !found || best.Id != test.Id {
+				t.Errorf("%s: best test sampled %s got %+v %v wanted id %d", name, samplingLabel(test.Sampling, test.SamplingSize, test.Lazy), best, err, test.Id)
+			}
+
+			pb, found, err := store.GetPersonalBest(test.bestParams())
+
+			if err != nil || !found || pb.Wpm != test.Wpm || pb.Attempts != 1 {
+				t.Errorf("%s: personal best sampled %s got %+v %v", name, samplingLabel(test.Sampling, test.SamplingSize, test.Lazy), pb, err)
+			}
+		}
+
+		bests, err := store.GetPersonalBests()
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(bests) != 3 || bests[0].Sampling != samplingTop || bests[0].Lazy || !bests[1].Lazy || bests[2].Sampling != samplingUniform {
+			t.Errorf("%s: GetPersonalBests not separated by sampling got %d bests", name, len(bests))
+		}
+	}
+}
+
+func TestStoreStopOnError(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		off := storeTest(50, 25, true)
+		word := storeTest(90, 25, true)
+		word.StopOnError = "word"
+
+		for _, test := range []*RacerTest{ off, word } {
+			if err := store.InsertTest(test); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+
+		if got, err := store.GetTest(word.Id); err != nil || got.StopOnError != "word" {
+			t.Errorf("%s: GetTest stop on error got %+v %v", name, got, err)
+		}
+
+		pb, found, err := store.GetPersonalBest(off.bestParams())
+
+		if err != nil || !found || pb.Wpm != 50 || pb.StopOnError != defaultStopOnError {
+			t.Errorf("%s: personal best without a stop mode got %+v %v", name, pb, err)
+		}
+
+		best, found, err := store.GetBestTest(word.bestParams())
+
+		if err != nil || !found || best.Id != word.Id {
+			t.Errorf("%s: best stop on word test got %+v %v wanted id %d", name, best, err, word.Id)
+		}
+	}
+}
+
+func TestStoreAdaptive(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		off := storeTest(50, 25, true)
+		high := storeTest(90, 25, true)
+		high.Adaptive = "high"
+
+		for _, test := range []*RacerTest{ off, high } {
+			if err := store.InsertTest(test); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+
+		if got, err := store.GetTest(high.Id); err != nil || got.Adaptive != "high" {
+			t.Errorf("%s: GetTest adaptive got %+v %v", name, got, err)
+		}
+
+		pb, found, err := store.GetPersonalBest(off.bestParams())
+
+		if err != nil || !found || pb.Wpm != 50 || pb.Adaptive != defaultAdaptive {
+			t.Errorf("%s: personal best without adaptive got %+v %v", name, pb, err)
+		}
+
+		bests, err := store.GetPersonalBests()
+
+		if err != nil || len(bests) != 2 || bests[0].Adaptive != "high" || bests[1].Adaptive != defaultAdaptive {
+			t.Errorf("%s: GetPersonalBests not separated by adaptive got %d bests %v", name, len(bests), err)
+		}
+	}
+}
+
+func TestStoreQuoteLength(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		short := storeTest(90, 0, true)
+		short.Mode = "quote"
+		short.QuoteId = 1
+		short.QuoteLength = "short"
+		long := storeTest(60, 0, true)
+		long.Mode = "quote"
+		long.QuoteId = 2
+		long.QuoteLength = "long"
+
+		for _, test := range []*RacerTest{ short, long } {
+			if err := store.InsertTest(test); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+
+		for _, test := range []*RacerTest{ short, long } {
+			pb, found, err := store.GetPersonalBest(test.bestParams())
+
+			if err != nil || !found || pb.TestId != test.Id || pb.Attempts != 1 || pb.QuoteLength != test.QuoteLength {
+				t.Errorf("%s: personal best of the %s quote got %+v %v", name, test.QuoteLength, pb, err)
+			}
+		}
+
+		if got, err := store.GetTest(long.Id); err != nil || got.QuoteLength != "long" {
+			t.Errorf("%s: GetTest quote length got %+v %v", name, got, err)
+		}
+
+		// a ghost of any quote length races the best quote test
+		params := long.bestParams()
+		params.quoteLength = "all"
+
+		if best, found, err := store.GetBestTest(params); err != nil || !found || best.Id != short.Id {
+			t.Errorf("%s: best test of all quote lengths got %+v %v wanted id %d", name, best, err, short.Id)
+		}
+	}
+}
+
+func TestStoreStatsAndPlayer(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		stats, err := store.GetGameStats()
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		stats.Total = 3
+		stats.TotalAttempted = 2
+
+		if err := store.UpdateGameStats(stats); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		got, err := store.GetGameStats()
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if *got != *stats {
+			t.Errorf("%s: game stats got %+v wanted %+v", name, got, stats)
+		}
+
+		if _, found, _ := store.GetPlayerInfo(); found {
+			t.Errorf("%s: player found in an empty store", name)
+		}
+
+		if err := store.InsertPlayerInfo(&PlayerInfoInsertParams{ name: "racer" }); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		info, found, err := store.GetPlayerInfo()
+
+		if err != nil || !found || info.name != "racer" {
+			t.Errorf("%s: player info got %+v found %v error %v", name, info, found, err)
+		}
+	}
+}
+
+// the test is inserted without a time like the tests taken in racer, duckdb
+// and the local time zone both have to be away from UTC to catch times
+// that are stored as the local wall clock time.
+func TestInsertTestTimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+
+	if err != nil {
+		t.Skip(err)
+	}
+
+	local := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = local })
+
+	store, err := OpenDuckDBStore(filepath.Join(t.TempDir(), "racer.db"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Close()
+
+	if _, err := store.DB().Exec("SET GLOBAL TimeZone = 'America/Los_Angeles'"); err != nil {
+		t.Fatal(err)
+	}
+
+	test := storeTest(50, 25, false)
+
+	if err := store.InsertTest(test); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := store.GetTests(&TestQuery{ Filter: StatsFilter{ Since: time.Now().Add(-time.Minute) } })
+
+	if err != nil || page.Total != 1 {
+		t.Errorf("recent tests got %+v %v wanted the inserted test", page, err)
+	}
+
+	d, err := store.GetDashboard(&StatsFilter{})
+
+	if err != nil || d.Days[len(d.Days) - 1].Tests != 1 {
+		t.Errorf("dashboard got %+v %v wanted the inserted test today", d, err)
+	}
+}
@@ -111,8 +111,8 @@ func (w *WordDb) Contains(name string) bool {
 	return ok
 }
 
-func (w *WordList) Save() error {
-	path := filepath.Join(defaultDataDir, w.Name + ".json")
+func (w *WordList) Save(dir string) error {
+	path := filepath.Join(dir, w.Name + ".json")
 
 	file, err := os.Create(path)
 